@@ -0,0 +1,158 @@
+package beaver
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// An AsyncWriter is an io.WriteCloser which buffers writes in a bounded
+// ring buffer, and writes them to the underlying io.Writer in another
+// goroutine, so callers aren't blocked by a slow Writer. When the buffer
+// is full, the incoming write is either dropped, which is the default,
+// or blocked until there is room. It is safe for concurrent use, and
+// must be closed to drain the buffer on shutdown.
+type AsyncWriter struct {
+	w io.Writer
+
+	mu      sync.Mutex
+	cond    *sync.Cond // signaled on any change of the buffer or state
+	buf     [][]byte
+	head, n int
+	block   bool
+	busy    bool // a write to w is in progress
+	closed  bool
+	dropped uint64
+	err     error // the first error from w since last Flush
+	done    chan struct{}
+}
+
+// NewAsyncWriter returns an AsyncWriter which buffers up to size writes
+// before writing them to w. If size is not positive, a size of 1024 is
+// applied.
+func NewAsyncWriter(w io.Writer, size int) *AsyncWriter {
+	if size <= 0 {
+		size = 1024
+	}
+
+	a := &AsyncWriter{w: w, buf: make([][]byte, size), done: make(chan struct{})}
+	a.cond = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+// Block sets whether writes are blocked, instead of dropped, when the
+// buffer is full.
+func (a *AsyncWriter) Block(b bool) *AsyncWriter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.block = b
+	return a
+}
+
+// Write implements the io.Writer interface. The p is copied to the
+// buffer and written later. A write dropped due to a full buffer is
+// counted by Dropped, and reports no error. It returns os.ErrClosed if
+// a is closed.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for !a.closed && a.n == len(a.buf) {
+		if !a.block {
+			a.dropped++
+			return len(p), nil
+		}
+		a.cond.Wait()
+	}
+
+	if a.closed {
+		return 0, os.ErrClosed
+	}
+
+	a.buf[(a.head+a.n)%len(a.buf)] = append([]byte(nil), p...)
+	a.n++
+	a.cond.Broadcast()
+	return len(p), nil
+}
+
+// Dropped returns the number of writes dropped due to a full buffer.
+func (a *AsyncWriter) Dropped() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.dropped
+}
+
+// Flush waits until all buffered writes are written to the underlying
+// Writer. It returns the first error reported by the Writer since last
+// Flush, if any.
+func (a *AsyncWriter) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for a.n > 0 || a.busy {
+		a.cond.Wait()
+	}
+
+	err := a.err
+	a.err = nil
+	return err
+}
+
+// Close drains the buffer and stops the writing goroutine. The
+// underlying Writer is closed if it implements io.Closer, unless it's
+// os.Stdout or os.Stderr. Writes blocked by a full buffer return
+// os.ErrClosed.
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return os.ErrClosed
+	}
+	a.closed = true
+	a.cond.Broadcast()
+	a.mu.Unlock()
+
+	<-a.done
+	err := a.err
+	if c, ok := a.w.(io.Closer); ok && c != os.Stdout && c != os.Stderr {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// run writes the buffered data to w until a is closed and drained.
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for {
+		for a.n == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if a.n == 0 {
+			return
+		}
+
+		p := a.buf[a.head]
+		a.buf[a.head] = nil
+		a.head = (a.head + 1) % len(a.buf)
+		a.n--
+		a.busy = true
+		a.cond.Broadcast()
+		a.mu.Unlock()
+
+		_, err := a.w.Write(p)
+
+		a.mu.Lock()
+		a.busy = false
+		if err != nil && a.err == nil {
+			a.err = err
+		}
+		a.cond.Broadcast()
+	}
+}
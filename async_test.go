@@ -0,0 +1,114 @@
+package beaver
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// A gateWriter writes to a bytes.Buffer, but blocks each write until
+// the gate is opened.
+type gateWriter struct {
+	bytes.Buffer
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{entered: make(chan struct{}, 10), gate: make(chan struct{})}
+}
+
+func (g *gateWriter) Write(p []byte) (int, error) {
+	g.entered <- struct{}{}
+	<-g.gate
+	return g.Buffer.Write(p)
+}
+
+func TestAsyncWriterDrop(t *testing.T) {
+	g := newGateWriter()
+	a := NewAsyncWriter(g, 2)
+
+	// the first write is held by the Writer, the next two fill the
+	// buffer and the rest are dropped
+	a.Write([]byte("1"))
+	<-g.entered
+	for _, s := range []string{"2", "3", "4", "5"} {
+		if _, err := a.Write([]byte(s)); err != nil {
+			t.Errorf("AsyncWriter.Write failed: %v", err)
+		}
+	}
+
+	if n := a.Dropped(); n != 2 {
+		t.Errorf("AsyncWriter.Dropped failed. Got: %d, Want: 2", n)
+	}
+
+	close(g.gate)
+	if err := a.Flush(); err != nil {
+		t.Error("AsyncWriter.Flush failed:", err)
+	}
+	if g.String() != "123" {
+		t.Errorf("AsyncWriter failed. Got: %s, Want: 123", g.String())
+	}
+
+	a.Close()
+	if _, err := a.Write([]byte("6")); err != os.ErrClosed {
+		t.Errorf("AsyncWriter.Write failed: got error %v after closed, want %v", err, os.ErrClosed)
+	}
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	g := newGateWriter()
+	a := NewAsyncWriter(g, 1).Block(true)
+
+	a.Write([]byte("1"))
+	<-g.entered
+	a.Write([]byte("2"))
+
+	// the write is blocked until the buffer has room
+	done := make(chan struct{})
+	go func() {
+		a.Write([]byte("3"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("AsyncWriter.Write failed: not blocked when buffer is full")
+	default:
+	}
+
+	close(g.gate)
+	<-done
+	if err := a.Close(); err != nil {
+		t.Error("AsyncWriter.Close failed:", err)
+	}
+
+	if g.String() != "123" || a.Dropped() != 0 {
+		t.Errorf("AsyncWriter failed: buffer not drained on Close. Got: %s, dropped %d", g.String(), a.Dropped())
+	}
+}
+
+func TestAsyncWriterLogger(t *testing.T) {
+	w := new(bytes.Buffer)
+	a := NewAsyncWriter(w, 0).Block(true)
+	l := NewLogger().Output(a)
+
+	for i := 0; i < 10; i++ {
+		l.Info(message)
+	}
+	a.Flush()
+
+	if n := bytes.Count(w.Bytes(), []byte(message)); n != 10 {
+		t.Errorf("AsyncWriter failed: %d logs written, want 10", n)
+	}
+}
+
+func TestAsyncWriterStderr(t *testing.T) {
+	// the standard outputs are kept open
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		NewAsyncWriter(f, 1).Close()
+		if _, err := f.Stat(); err != nil {
+			t.Fatalf("AsyncWriter.Close failed: %s closed: %v", f.Name(), err)
+		}
+	}
+}
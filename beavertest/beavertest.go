@@ -0,0 +1,174 @@
+// Package beavertest provides sinks for testing code which logs by
+// package beaver. A Recorder records the entries of a Logger, so they can
+// be asserted without matching the encoded output. A TB sink routes the
+// logs to testing.T.Log, so they are shown with the test which writes
+// them.
+package beavertest
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Hunsin/beaver"
+)
+
+// A Recorder is a beaver.Sink which records entries of all levels. It is
+// safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []beaver.Entry
+}
+
+// NewLogger returns a beaver.Logger which writes to a new Recorder, and
+// the Recorder.
+func NewLogger() (*beaver.Logger, *Recorder) {
+	r := &Recorder{}
+	return beaver.NewLogger().Sinks(r), r
+}
+
+// Enabled implements the beaver.Sink interface.
+func (r *Recorder) Enabled(lv int) bool {
+	return true
+}
+
+// Log implements the beaver.Sink interface.
+func (r *Recorder) Log(e *beaver.Entry) error {
+	c := *e
+	c.Fields = append([]interface{}(nil), e.Fields...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, c)
+	return nil
+}
+
+// Entries returns the recorded entries of levels lv in order.
+func (r *Recorder) Entries(lv int) []beaver.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var es []beaver.Entry
+	for _, e := range r.entries {
+		if e.Level&lv != 0 {
+			es = append(es, e)
+		}
+	}
+	return es
+}
+
+// Reset removes all recorded entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Find returns the first recorded entry of levels lv with message msg
+// and key-value pairs kv in its fields. The values are compared by
+// reflect.DeepEqual. It returns false if not found.
+func (r *Recorder) Find(lv int, msg string, kv ...interface{}) (beaver.Entry, bool) {
+	for _, e := range r.Entries(lv) {
+		if e.Message == msg && hasFields(e.Fields, kv) {
+			return e, true
+		}
+	}
+	return beaver.Entry{}, false
+}
+
+// AssertLogged reports an error to t if no entry is recorded with levels
+// lv, message msg and key-value pairs kv, as Find does.
+func (r *Recorder) AssertLogged(t testing.TB, lv int, msg string, kv ...interface{}) {
+	t.Helper()
+	if _, ok := r.Find(lv, msg, kv...); !ok {
+		t.Errorf("beavertest: no %s log %q with fields %v; recorded:\n%s", beaver.FormatLevel(lv), msg, kv, r)
+	}
+}
+
+// AssertNotLogged reports an error to t if any entry is recorded with
+// levels lv, message msg and key-value pairs kv, as Find does.
+func (r *Recorder) AssertNotLogged(t testing.TB, lv int, msg string, kv ...interface{}) {
+	t.Helper()
+	if e, ok := r.Find(lv, msg, kv...); ok {
+		t.Errorf("beavertest: unexpected log %q with fields %v at %s:%d", e.Message, e.Fields, e.File, e.Line)
+	}
+}
+
+// AssertCount reports an error to t if the number of entries recorded
+// with levels lv is not n.
+func (r *Recorder) AssertCount(t testing.TB, lv int, n int) {
+	t.Helper()
+	if got := len(r.Entries(lv)); got != n {
+		t.Errorf("beavertest: %d %s logs recorded, want %d; recorded:\n%s", got, beaver.FormatLevel(lv), n, r)
+	}
+}
+
+// String returns the recorded entries, one per line, with the caller,
+// the name of level, the message and the fields.
+func (r *Recorder) String() string {
+	var b strings.Builder
+	for _, e := range r.Entries(beaver.Lall) {
+		fmt.Fprintf(&b, "%s:%d: %s %s", e.File, e.Line, beaver.FormatLevel(e.Level), e.Message)
+		for i := 0; i < len(e.Fields); i += 2 {
+			fmt.Fprintf(&b, " %v=", e.Fields[i])
+			if i+1 < len(e.Fields) {
+				fmt.Fprint(&b, e.Fields[i+1])
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// hasFields reports whether key-value pairs kv are all in fields.
+func hasFields(fields, kv []interface{}) bool {
+	for i := 0; i < len(kv); i += 2 {
+		var v interface{}
+		if i+1 < len(kv) {
+			v = kv[i+1]
+		}
+
+		found := false
+		for j := 0; j+1 < len(fields); j += 2 {
+			if reflect.DeepEqual(fields[j], kv[i]) && reflect.DeepEqual(fields[j+1], v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Writer returns an io.Writer which writes each write to t.Log, without
+// the trailing newline. It can be used as output of beaver.Logger and
+// httplog.Logger. Writing after the test completes causes a panic.
+//
+// The file and line reported by t are inside the package beaver rather
+// than where the log is written, as t.Helper can't mark the frames of
+// the Logger. Set the flag log.Lshortfile of the Logger to include the
+// caller in the log instead.
+func Writer(t testing.TB) io.Writer {
+	return tbWriter{t}
+}
+
+type tbWriter struct {
+	t testing.TB
+}
+
+func (w tbWriter) Write(p []byte) (int, error) {
+	w.t.Helper()
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// TB returns a beaver.WriterSink which writes logs to t.Log. Like other
+// WriterSinks, its level, formatter and tags are configurable.
+func TB(t testing.TB) *beaver.WriterSink {
+	return beaver.NewWriterSink(Writer(t))
+}
@@ -0,0 +1,88 @@
+package beavertest
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Hunsin/beaver"
+)
+
+// A fakeTB records the errors reported by assertions.
+type fakeTB struct {
+	testing.TB
+	errs []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, v ...interface{}) {
+	f.errs = append(f.errs, fmt.Sprintf(format, v...))
+}
+
+func TestRecorder(t *testing.T) {
+	l, r := NewLogger()
+	l.Named("db").Errorw("query failed", "table", "users", "retry", 3)
+	l.Info("done")
+
+	e, ok := r.Find(beaver.Lerror, "query failed", "retry", 3)
+	if !ok {
+		t.Fatalf("Recorder.Find failed: entry not found. Recorded:\n%s", r)
+	}
+	if e.Name != "db" || filepath.Base(e.File) != "beavertest_test.go" || e.Line == 0 {
+		t.Errorf("Recorder failed: name or caller not recorded. Got: %+v", e)
+	}
+
+	r.AssertLogged(t, beaver.Lerror, "query failed", "table", "users")
+	r.AssertLogged(t, beaver.Lall, "done")
+	r.AssertNotLogged(t, beaver.Lwarn, "done")
+	r.AssertCount(t, beaver.Lerror|beaver.Linfo, 2)
+
+	// failures are reported
+	f := &fakeTB{}
+	r.AssertLogged(f, beaver.Lerror, "query failed", "retry", 4)
+	r.AssertNotLogged(f, beaver.Linfo, "done")
+	r.AssertCount(f, beaver.Ldebug, 1)
+	if len(f.errs) != 3 {
+		t.Errorf("Recorder failed: %d assertions failed, want 3: %q", len(f.errs), f.errs)
+	}
+
+	r.Reset()
+	r.AssertCount(t, beaver.Lall, 0)
+}
+
+// A logTB records the arguments of Log.
+type logTB struct {
+	testing.TB
+	bytes.Buffer
+}
+
+func (l *logTB) Log(v ...interface{}) {
+	fmt.Fprintln(&l.Buffer, v...)
+}
+
+func (l *logTB) Helper() {}
+
+func TestTB(t *testing.T) {
+	tb := &logTB{}
+	l := beaver.NewLogger().Flags(0).Sinks(TB(tb).Level(beaver.Lwarn))
+	l.Warn("Hello")
+	l.Info("ignored")
+
+	if tb.String() != "WARN : Hello\n" {
+		t.Errorf("TB failed. Got: %q", tb.String())
+	}
+
+	// the caller is included by flag
+	tb.Reset()
+	l.Flags(log.Lshortfile).Warn("Hello")
+	if !strings.HasPrefix(tb.String(), "beavertest_test.go:") {
+		t.Errorf("TB failed: caller not included. Got: %q", tb.String())
+	}
+
+	// logs are also shown with the test
+	beaver.NewLogger().Sinks(TB(t)).Info("Hello testing.T")
+}
@@ -0,0 +1,67 @@
+package beaver
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+
+	// ColorLogTag is DefaultLogTag colored by ANSI escape codes
+	ColorLogTag = LTag{
+		"\x1b[1;31mFATAL\x1b[0m:",
+		"\x1b[1;35mPANIC\x1b[0m:",
+		"\x1b[31mERROR\x1b[0m:",
+		"\x1b[33mWARN \x1b[0m:",
+		"\x1b[32mINFO \x1b[0m:",
+		"\x1b[36mDEBUG\x1b[0m:",
+		"\x1b[90mTRACE\x1b[0m:",
+	}
+
+	// ColorBracketLogTag is BracketLogTag colored by ANSI escape codes
+	ColorBracketLogTag = LTag{
+		"[\x1b[1;31mFATAL\x1b[0m]",
+		"[\x1b[1;35mPANIC\x1b[0m]",
+		"[\x1b[31mERROR\x1b[0m]",
+		"[\x1b[33mWARN \x1b[0m]",
+		"[\x1b[32mINFO \x1b[0m]",
+		"[\x1b[36mDEBUG\x1b[0m]",
+		"[\x1b[90mTRACE\x1b[0m]",
+	}
+)
+
+// colorable reports whether w is a terminal which colors are written
+// to. It's false if the environment variable NO_COLOR is not empty.
+func colorable(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stripColor returns s without ANSI escape sequences.
+func stripColor(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' || i+1 == len(s) || s[i+1] != '[' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		// skip parameters until the final byte of the sequence
+		for i += 2; i < len(s) && (s[i] < 0x40 || s[i] > 0x7e); i++ {
+		}
+	}
+	return b.String()
+}
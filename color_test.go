@@ -0,0 +1,52 @@
+package beaver
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestStripColor(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                        "",
+		"INFO :":                  "INFO :",
+		"\x1b[1;31mFATAL\x1b[0m:": "FATAL:",
+		"[\x1b[33mWARN \x1b[0m]":  "[WARN ]",
+		"\x1b[":                   "",
+		"a\x1bb":                  "a\x1bb",
+	} {
+		if got := stripColor(in); got != want {
+			t.Errorf("stripColor(%q) failed. Got: %q, Want: %q", in, got, want)
+		}
+	}
+}
+
+func TestColorTags(t *testing.T) {
+	// colors are removed if output is not a terminal
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w).Tags(ColorLogTag)
+	l.Info(message)
+	if !reg.Match(w.Bytes()) {
+		t.Errorf("Logger.Tags failed: colors written to non-terminal. Got: %q", w)
+	}
+
+	// as if the output is a terminal
+	w.Reset()
+	l.out.update(func(c *outConfig) { c.color = true })
+	l.Info(message)
+	if !bytes.Contains(w.Bytes(), []byte(ColorLogTag.Info+" "+message)) {
+		t.Errorf("Logger.Tags failed: colors not written to terminal. Got: %q", w)
+	}
+
+	w.Reset()
+	l.Sinks(NewWriterSink(w).Tags(ColorBracketLogTag)).Warn(message)
+	if got := w.String(); !bytes.Contains(w.Bytes(), []byte("[WARN ] "+message)) {
+		t.Errorf("WriterSink.Tags failed: colors written to non-terminal. Got: %q", got)
+	}
+
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	if colorable(os.Stdout) {
+		t.Error("colorable failed: colors enabled with NO_COLOR set")
+	}
+}
@@ -0,0 +1,27 @@
+package beaver
+
+import "context"
+
+// ctxKey is the key of Logger in a context.Context.
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx which carries l. The l can be
+// retrieved by FromContext.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the Logger carried by ctx, or the default Logger
+// if there's none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*Logger); ok {
+		return l
+	}
+	return stdLgr
+}
+
+// ContextWith returns a copy of ctx which carries a child Logger of
+// FromContext(ctx) with key-value pairs kv, as Logger.With does.
+func ContextWith(ctx context.Context, kv ...interface{}) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(kv...))
+}
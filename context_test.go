@@ -0,0 +1,31 @@
+package beaver
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if FromContext(ctx) != stdLgr {
+		t.Error("FromContext failed: default Logger not returned")
+	}
+
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w).Flags(0)
+	ctx = NewContext(ctx, l)
+	if FromContext(ctx) != l {
+		t.Error("FromContext failed: Logger of NewContext not returned")
+	}
+
+	ctx = ContextWith(ctx, "request_id", "abc")
+	FromContext(ContextWith(ctx, "user", 42)).Info(message)
+	FromContext(ctx).Info(message)
+
+	want := "INFO : " + message + " request_id=abc user=42\n" +
+		"INFO : " + message + " request_id=abc\n"
+	if w.String() != want {
+		t.Errorf("ContextWith failed.\nGot:\n%s\nWant:\n%s", w, want)
+	}
+}
@@ -0,0 +1,218 @@
+package beaver
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// magic is the leading bytes of an encrypted JSON file.
+const magic = "BVRJ"
+
+// The envelope versions and key derivation methods of encrypted files.
+const (
+	cryptV1 = 1
+
+	kdfRaw    = 0
+	kdfScrypt = 1
+)
+
+// parameters of scrypt for newly written files; N = 1<<scryptLogN
+const (
+	scryptLogN = 15
+	scryptR    = 8
+	scryptP    = 1
+	saltSize   = 16
+)
+
+// the maximum memory, 128 * r * N bytes, and parallelization of scrypt
+// accepted from a file header, which is read before authenticated;
+// larger ones might exhaust the memory or CPU
+const (
+	maxScryptMem = 256 << 20
+	maxScryptP   = 1
+)
+
+var errEnvelope = errors.New("beaver: malformed encrypted file")
+
+// A Key provides the AES key used to encrypt or decrypt JSON files.
+// It is either a raw key or derived from a passphrase.
+type Key struct {
+	raw  []byte
+	pass []byte
+}
+
+// RawKey returns a Key using k as AES key directly. The length of k
+// must be 16, 24 or 32 bytes to select AES-128, AES-192 or AES-256.
+func RawKey(k []byte) Key {
+	return Key{raw: k}
+}
+
+// Passphrase returns a Key which derives an AES-256 key from p with
+// scrypt. A random salt is generated each time a file is written.
+func Passphrase(p string) Key {
+	return Key{pass: []byte(p)}
+}
+
+// OpenEncrypted decrypts the file in given path with k and stores the
+// JSON-encoded result in j. The file must be written by WriteFileEncrypted
+// with the same Key.
+func (j *JSONPod) OpenEncrypted(path string, k Key) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	pt, err := decrypt(b, k)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(pt, j.v)
+}
+
+// WriteFileEncrypted writes JSON-encoded data of j.v, encrypted by
+// AES-GCM with k, to a file by given path. The plaintext is never written
+// to disk. The file is created with permission mode 0600 and truncated
+// if it already exists.
+func (j *JSONPod) WriteFileEncrypted(path string, k Key) error {
+	pt, err := json.Marshal(j.v)
+	if err != nil {
+		return err
+	}
+
+	b, err := encrypt(pt, k)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// encrypt seals pt and returns the envelope. The layout of version 1 is:
+//
+//	magic | version | kdf | [logN | r | p | salt] | nonce | ciphertext
+//
+// where the bracketed part presents only if the key is a passphrase.
+// The header before nonce is authenticated as additional data.
+func encrypt(pt []byte, k Key) ([]byte, error) {
+	hdr := []byte(magic)
+	hdr = append(hdr, cryptV1)
+
+	var key []byte
+	if k.pass == nil {
+		hdr = append(hdr, kdfRaw)
+		key = k.raw
+	} else {
+		salt := make([]byte, saltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, err
+		}
+
+		hdr = append(hdr, kdfScrypt, scryptLogN, scryptR, scryptP)
+		hdr = append(hdr, salt...)
+
+		var err error
+		if key, err = scrypt.Key(k.pass, salt, 1<<scryptLogN, scryptR, scryptP, 32); err != nil {
+			return nil, err
+		}
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(hdr, nonce...)
+	return aead.Seal(out, nonce, pt, hdr), nil
+}
+
+// decrypt opens the envelope b produced by encrypt.
+func decrypt(b []byte, k Key) ([]byte, error) {
+	n := len(magic) + 2
+	if len(b) < n || !bytes.Equal(b[:len(magic)], []byte(magic)) {
+		return nil, errEnvelope
+	}
+	if b[len(magic)] != cryptV1 {
+		return nil, errors.New("beaver: unsupported version of encrypted file")
+	}
+
+	var key []byte
+	switch b[n-1] {
+	case kdfRaw:
+		if k.pass != nil {
+			return nil, errors.New("beaver: file is encrypted with a raw key, not a passphrase")
+		}
+		key = k.raw
+	case kdfScrypt:
+		if k.pass == nil {
+			return nil, errors.New("beaver: file is encrypted with a passphrase, not a raw key")
+		}
+		if len(b) < n+3+saltSize {
+			return nil, errEnvelope
+		}
+
+		logN, r, p := b[n], b[n+1], b[n+2]
+		if !scryptBounded(logN, r, p) {
+			return nil, errEnvelope
+		}
+		salt := b[n+3 : n+3+saltSize]
+		n += 3 + saltSize
+
+		var err error
+		if key, err = scrypt.Key(k.pass, salt, 1<<logN, int(r), int(p), 32); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("beaver: unknown key derivation of encrypted file")
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < n+aead.NonceSize() {
+		return nil, errEnvelope
+	}
+	hdr, nonce := b[:n], b[n:n+aead.NonceSize()]
+	return aead.Open(nil, nonce, b[n+aead.NonceSize():], hdr)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
+
+// scryptBounded reports whether the parameters of scrypt are in the
+// bounds accepted from a file header.
+func scryptBounded(logN, r, p byte) bool {
+	if logN == 0 || logN > 30 || r == 0 || p == 0 || p > maxScryptP {
+		return false
+	}
+	return 128*uint64(r)<<logN <= maxScryptMem
+}
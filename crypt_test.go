@@ -0,0 +1,104 @@
+package beaver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestEncryptedFile(t *testing.T) {
+	s := sample{
+		Name: "Beaver",
+		Year: 2017,
+		Fast: true,
+	}
+
+	keys := map[string]Key{
+		"raw":        RawKey(bytes.Repeat([]byte{7}, 32)),
+		"passphrase": Passphrase("open sesame"),
+	}
+
+	for name, k := range keys {
+		if err := JSON(&s).WriteFileEncrypted("temp.enc", k); err != nil {
+			t.Fatalf("JSONPod.WriteFileEncrypted with %s key failed: %v", name, err)
+		}
+
+		b, _ := ioutil.ReadFile("temp.enc")
+		if bytes.Contains(b, []byte("Beaver")) {
+			t.Errorf("JSONPod.WriteFileEncrypted with %s key failed: plaintext found in file", name)
+		}
+
+		out := sample{}
+		if err := JSON(&out).OpenEncrypted("temp.enc", k); err != nil {
+			t.Fatalf("JSONPod.OpenEncrypted with %s key failed: %v", name, err)
+		}
+
+		if out != s {
+			t.Errorf("JSONPod.OpenEncrypted with %s key failed\nGot:  %v\nWant: %v", name, out, s)
+		}
+	}
+	os.Remove("temp.enc")
+}
+
+func TestEncryptedFileWrongKey(t *testing.T) {
+	s := sample{Name: "Beaver"}
+	defer os.Remove("temp.enc")
+
+	if err := JSON(&s).WriteFileEncrypted("temp.enc", Passphrase("right")); err != nil {
+		t.Fatal("JSONPod.WriteFileEncrypted failed:", err)
+	}
+
+	out := sample{}
+	if err := JSON(&out).OpenEncrypted("temp.enc", Passphrase("wrong")); err == nil {
+		t.Error("JSONPod.OpenEncrypted failed: wrong passphrase should cause an error")
+	}
+	if err := JSON(&out).OpenEncrypted("temp.enc", RawKey(make([]byte, 32))); err == nil {
+		t.Error("JSONPod.OpenEncrypted failed: raw key should not open file encrypted with passphrase")
+	}
+
+	// tampering the header must be detected
+	b, _ := ioutil.ReadFile("temp.enc")
+	b[len(magic)+3]++
+	ioutil.WriteFile("temp.enc", b, 0600)
+	if err := JSON(&out).OpenEncrypted("temp.enc", Passphrase("right")); err == nil {
+		t.Error("JSONPod.OpenEncrypted failed: modified header should cause an error")
+	}
+
+	// scrypt parameters too large are rejected before deriving the key
+	for i, v := range []byte{30, 255, 255} {
+		h := append([]byte(nil), b...)
+		h[len(magic)+2+i] = v
+		ioutil.WriteFile("temp.enc", h, 0600)
+		if err := JSON(&out).OpenEncrypted("temp.enc", Passphrase("right")); err != errEnvelope {
+			t.Errorf("JSONPod.OpenEncrypted failed: scrypt parameter %d of %d should be rejected. Got: %v", i, v, err)
+		}
+	}
+
+	if err := JSON(&s).WriteFileEncrypted("temp.enc", RawKey([]byte("short"))); err == nil {
+		t.Error("JSONPod.WriteFileEncrypted failed: invalid key size should cause an error")
+	}
+}
+
+func TestScryptBounded(t *testing.T) {
+	for _, c := range []struct {
+		logN, r, p byte
+		ok         bool
+	}{
+		{scryptLogN, scryptR, scryptP, true},
+		{18, 8, 1, true}, // 256 MiB
+		{20, 2, 1, true},
+		{19, 8, 1, false},
+		{18, 9, 1, false},
+		{15, 8, 2, false},
+		{0, 8, 1, false},
+		{15, 0, 1, false},
+		{15, 8, 0, false},
+		{31, 1, 1, false},
+		{255, 255, 255, false},
+	} {
+		if ok := scryptBounded(c.logN, c.r, c.p); ok != c.ok {
+			t.Errorf("scryptBounded(%d, %d, %d) failed. Got: %v, Want: %v", c.logN, c.r, c.p, ok, c.ok)
+		}
+	}
+}
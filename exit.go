@@ -0,0 +1,38 @@
+package beaver
+
+import "sync"
+
+var exitHandlers struct {
+	mu sync.Mutex
+	fn []func()
+}
+
+// RegisterExitHandler adds f to the handlers which run before a Logger
+// exits the program by Fatal, Fatalf, Fatalw or Exit. The handlers run
+// in reverse order of registration, like deferred calls. A panic in a
+// handler is recovered so the rest still run.
+func RegisterExitHandler(f func()) {
+	exitHandlers.mu.Lock()
+	defer exitHandlers.mu.Unlock()
+	exitHandlers.fn = append(exitHandlers.fn, f)
+}
+
+// runExitHandlers runs and removes all registered handlers, so they run
+// at most once even if the exit function doesn't terminate the program.
+func runExitHandlers() {
+	exitHandlers.mu.Lock()
+	fn := exitHandlers.fn
+	exitHandlers.fn = nil
+	exitHandlers.mu.Unlock()
+
+	for i := len(fn) - 1; i >= 0; i-- {
+		runExitHandler(fn[i])
+	}
+}
+
+func runExitHandler(f func()) {
+	defer func() {
+		recover()
+	}()
+	f()
+}
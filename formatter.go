@@ -0,0 +1,183 @@
+package beaver
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// An Entry is a log generated by a Logger, which is encoded by a
+// Formatter.
+type Entry struct {
+	Time    time.Time
+	Level   int           // Lfatal, Lpanic, ..., Ltrace or a custom level
+	Tag     string        // the tag of Level in Logger's LTag
+	Name    string        // the name of Logger; empty if not named
+	Prefix  string        // the prefix of Logger
+	Message string        // without trailing newline
+	File    string        // full path of the caller's file; empty if not reported
+	Line    int           // line number of the caller
+	Fields  []interface{} // key-value pairs
+	Flags   int           // the flags of Logger, as defined in package "log"
+
+	pc uintptr // program counter of the caller; zero if unknown
+}
+
+// caller returns "file:line" of the caller, which file is either the
+// full path or the base name depending on e.Flags. An empty string is
+// returned if the flags include neither log.Lshortfile nor log.Llongfile,
+// or the caller isn't reported.
+func (e *Entry) caller() string {
+	if e.File == "" || e.Flags&(log.Lshortfile|log.Llongfile) == 0 {
+		return ""
+	}
+
+	file := e.File
+	if e.Flags&log.Lshortfile != 0 {
+		file = filepath.Base(file)
+	}
+	return file + ":" + strconv.Itoa(e.Line)
+}
+
+// A Formatter encodes an Entry to bytes which are written to the output
+// of a Logger. The result should end with a newline.
+type Formatter interface {
+	Format(e *Entry) ([]byte, error)
+}
+
+// A TextFormatter encodes entries as the standard package "log" does,
+// with the tag of level and the name of Logger, if any, in front of the
+// message. Fields are appended in logfmt style. For example:
+//
+//	2018/02/06 00:31:28 INFO : db: Hello World! user=42
+type TextFormatter struct{}
+
+// Format implements the Formatter interface.
+func (TextFormatter) Format(e *Entry) ([]byte, error) {
+	var b bytes.Buffer
+	if e.Flags&log.Lmsgprefix == 0 {
+		b.WriteString(e.Prefix)
+	}
+
+	if e.Flags&(log.Ldate|log.Ltime|log.Lmicroseconds) != 0 {
+		t := e.Time
+		if e.Flags&log.LUTC != 0 {
+			t = t.UTC()
+		}
+		if e.Flags&log.Ldate != 0 {
+			b.WriteString(t.Format("2006/01/02 "))
+		}
+		if e.Flags&log.Lmicroseconds != 0 {
+			b.WriteString(t.Format("15:04:05.000000 "))
+		} else if e.Flags&log.Ltime != 0 {
+			b.WriteString(t.Format("15:04:05 "))
+		}
+	}
+
+	if c := e.caller(); c != "" {
+		b.WriteString(c + ": ")
+	}
+	if e.Flags&log.Lmsgprefix != 0 {
+		b.WriteString(e.Prefix)
+	}
+
+	if e.Tag != "" {
+		b.WriteString(e.Tag + " ")
+	}
+	if e.Name != "" {
+		b.WriteString(e.Name + ": ")
+	}
+	b.WriteString(e.Message)
+	appendLogfmt(&b, e.Fields)
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+// A JSONFormatter encodes each entry as a JSON object in one line. The
+// keys are "time", "level", "logger", "prefix", "msg", "caller" and then
+// the keys of fields. The time is presented only if the Logger's flags
+// include any of log.Ldate, log.Ltime and log.Lmicroseconds; the caller
+// only if they include log.Lshortfile or log.Llongfile. The name of
+// Logger and the prefix are omitted if empty. A field which key collides
+// with the above is prefixed with "fields.". For example:
+//
+//	{"time":"2018-02-06T00:31:28.123+08:00","level":"info","msg":"Hello World!","user":42}
+type JSONFormatter struct{}
+
+// reserved keys of JSONFormatter
+var jsonKeys = map[string]bool{
+	"time":   true,
+	"level":  true,
+	"logger": true,
+	"prefix": true,
+	"msg":    true,
+	"caller": true,
+}
+
+// Format implements the Formatter interface.
+func (JSONFormatter) Format(e *Entry) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+
+	if e.Flags&(log.Ldate|log.Ltime|log.Lmicroseconds) != 0 {
+		t := e.Time
+		if e.Flags&log.LUTC != 0 {
+			t = t.UTC()
+		}
+		writeJSONField(&b, "time", t.Format(time.RFC3339Nano))
+	}
+
+	writeJSONField(&b, "level", levelName(e.Level))
+	if e.Name != "" {
+		writeJSONField(&b, "logger", e.Name)
+	}
+	if e.Prefix != "" {
+		writeJSONField(&b, "prefix", e.Prefix)
+	}
+	writeJSONField(&b, "msg", e.Message)
+	if c := e.caller(); c != "" {
+		writeJSONField(&b, "caller", c)
+	}
+
+	for i := 0; i < len(e.Fields); i += 2 {
+		k := logfmtValue(e.Fields[i])
+		if jsonKeys[k] {
+			k = "fields." + k
+		}
+
+		var v interface{} = "(MISSING)"
+		if i+1 < len(e.Fields) {
+			v = e.Fields[i+1]
+		}
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		writeJSONField(&b, k, v)
+	}
+
+	b.WriteString("}\n")
+	return b.Bytes(), nil
+}
+
+// writeJSONField writes the JSON-encoded k and v to b, preceded by a
+// comma if b isn't at the beginning of an object. If v can't be encoded,
+// its string form is written instead.
+func writeJSONField(b *bytes.Buffer, k string, v interface{}) {
+	if b.Bytes()[b.Len()-1] != '{' {
+		b.WriteByte(',')
+	}
+
+	key, _ := json.Marshal(k)
+	b.Write(key)
+	b.WriteByte(':')
+
+	val, err := json.Marshal(v)
+	if err != nil {
+		val, _ = json.Marshal(logfmtValue(v))
+	}
+	b.Write(val)
+}
@@ -0,0 +1,89 @@
+package beaver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+	"time"
+)
+
+var entry = Entry{
+	Time:    time.Date(2018, 2, 6, 0, 31, 28, 123456789, time.UTC),
+	Level:   Linfo,
+	Tag:     "INFO :",
+	Prefix:  "app ",
+	Message: message,
+	File:    "/path/to/main.go",
+	Line:    27,
+	Fields:  []interface{}{"user", 42, "msg", "dup", "err", errors.New("oops")},
+}
+
+func TestTextFormatter(t *testing.T) {
+	cases := []struct {
+		flag int
+		want string
+	}{
+		{0, "app INFO : " + message + " user=42 msg=dup err=oops\n"},
+		{log.LstdFlags, "app 2018/02/06 00:31:28 INFO : " + message + " user=42 msg=dup err=oops\n"},
+		{log.Ltime | log.Lmicroseconds | log.Lshortfile, "app 00:31:28.123456 main.go:27: INFO : " + message + " user=42 msg=dup err=oops\n"},
+		{log.Llongfile | log.Lmsgprefix, "/path/to/main.go:27: app INFO : " + message + " user=42 msg=dup err=oops\n"},
+	}
+
+	for _, c := range cases {
+		e := entry
+		e.Flags = c.flag
+
+		b, err := TextFormatter{}.Format(&e)
+		if err != nil {
+			t.Fatal("TextFormatter.Format failed:", err)
+		}
+		if string(b) != c.want {
+			t.Errorf("TextFormatter.Format with flags %d failed\nGot:  %q\nWant: %q", c.flag, b, c.want)
+		}
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	e := entry
+	e.Flags = log.LstdFlags | log.Lshortfile
+
+	b, err := JSONFormatter{}.Format(&e)
+	if err != nil {
+		t.Fatal("JSONFormatter.Format failed:", err)
+	}
+
+	want := `{"time":"2018-02-06T00:31:28.123456789Z","level":"info","prefix":"app ","msg":"` + message +
+		`","caller":"main.go:27","user":42,"fields.msg":"dup","err":"oops"}` + "\n"
+	if string(b) != want {
+		t.Errorf("JSONFormatter.Format failed\nGot:  %s\nWant: %s", b, want)
+	}
+
+	// without time and caller
+	e.Flags, e.Prefix, e.Fields = 0, "", []interface{}{"odd"}
+	b, _ = JSONFormatter{}.Format(&e)
+
+	want = `{"level":"info","msg":"` + message + `","odd":"(MISSING)"}` + "\n"
+	if string(b) != want {
+		t.Errorf("JSONFormatter.Format failed\nGot:  %s\nWant: %s", b, want)
+	}
+}
+
+func TestLoggerFormatter(t *testing.T) {
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w).Formatter(JSONFormatter{}).Prefix("app").Flags(log.Lshortfile)
+	l.With("user", 42).Warnf("Logging %d messages", 2)
+
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(w.Bytes(), &out); err != nil {
+		t.Fatalf("Logger.Formatter failed: invalid JSON %s: %v", w, err)
+	}
+
+	if out["level"] != "warn" || out["prefix"] != "app" || out["msg"] != "Logging 2 messages" || out["user"] != 42.0 {
+		t.Errorf("Logger.Formatter failed. Got: %s", w)
+	}
+	if c, _ := out["caller"].(string); len(c) < 18 || c[:18] != "formatter_test.go:" {
+		t.Errorf("Logger.Formatter failed: caller not reported. Got: %s", w)
+	}
+}
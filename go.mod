@@ -0,0 +1,5 @@
+module github.com/Hunsin/beaver
+
+go 1.20
+
+require golang.org/x/crypto v0.31.0
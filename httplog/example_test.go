@@ -4,11 +4,13 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Hunsin/beaver"
 	"github.com/Hunsin/beaver/httplog"
 )
 
 var handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-	// do your staff
+	// do your staff, logging with request ID, method and path
+	beaver.FromContext(r.Context()).Info("Hello")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 })
@@ -23,6 +25,9 @@ func Example() {
 	// or, simply call httplog.File()
 	l = httplog.File("http.log")
 
+	// or reopen the file on SIGHUP, after it's rotated by logrotate
+	l.ReopenFile("http.log")
+
 	// you can chain you configurations
 	l.File("new.log").Prefix("my-app").TimeFormat(time.RFC1123)
 
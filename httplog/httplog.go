@@ -1,6 +1,8 @@
 package httplog
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
@@ -8,8 +10,13 @@ import (
 	"os"
 	"sync"
 	"time"
+
+	"github.com/Hunsin/beaver"
 )
 
+// RequestIDHeader is the HTTP header which carries the ID of a request.
+const RequestIDHeader = "X-Request-Id"
+
 // A Logger represents an logging object that records a series of HTTP
 // requests, writing data to an io.Writer. It guarantees to serialize
 // access to the Writer.
@@ -18,6 +25,7 @@ type Logger struct {
 	prefix  []interface{}
 	out     io.Writer
 	ph      PanicHandler
+	base    *beaver.Logger
 	mu      sync.Mutex
 }
 
@@ -33,6 +41,18 @@ func (l *Logger) File(name string) *Logger {
 	return l.Output(f)
 }
 
+// ReopenFile is like File, but the file is opened by
+// beaver.OpenReopenWriter, which reopens it on SIGHUP. The file is
+// closed when the output destination is changed.
+func (l *Logger) ReopenFile(name string) *Logger {
+	f, err := beaver.OpenReopenWriter(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return l.Output(f)
+}
+
 // Output sets the output destination for the logger.
 func (l *Logger) Output(w io.Writer) *Logger {
 	l.mu.Lock()
@@ -83,14 +103,41 @@ func (l *Logger) Recover(h PanicHandler) *Logger {
 	return l
 }
 
+// Context sets the beaver.Logger which request-scoped loggers are
+// derived from. If bl is nil, the one carried by the request context,
+// or the default beaver.Logger, is applied.
+func (l *Logger) Context(bl *beaver.Logger) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.base = bl
+	return l
+}
+
 // Listen is a http middleware that records the request event and
-// calls the given http.Handler.
+// calls the given http.Handler. The request context carries a
+// beaver.Logger with fields "request_id", "method" and "path", which
+// can be retrieved by beaver.FromContext. The request ID is the value of
+// RequestIDHeader, or a random one if the header is absent.
 func (l *Logger) Listen(h http.Handler) http.HandlerFunc {
 	if l.ph != nil {
 		h = chainPanicHandler(h, l.ph)
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		l.mu.Lock()
+		base := l.base
+		l.mu.Unlock()
+		if base == nil {
+			base = beaver.FromContext(r.Context())
+		}
+
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		r = r.WithContext(beaver.NewContext(r.Context(),
+			base.With("request_id", id, "method", r.Method, "path", r.URL.Path)))
+
 		now := time.Now()
 		rec := &recorder{w, 0}
 		h.ServeHTTP(rec, r)
@@ -114,6 +161,13 @@ func (l *Logger) Listen(h http.Handler) http.HandlerFunc {
 	}
 }
 
+// newRequestID returns a random ID of 16 hex digits.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 // File returns a new Logger which output destination is set to
 // named file.
 func File(name string) *Logger {
@@ -127,5 +181,5 @@ func New(w io.Writer) *Logger {
 	if w == nil {
 		w = os.Stdout
 	}
-	return &Logger{time.RFC3339Nano, []interface{}{}, w, nil, sync.Mutex{}}
+	return &Logger{time.RFC3339Nano, []interface{}{}, w, nil, nil, sync.Mutex{}}
 }
@@ -8,8 +8,11 @@ import (
 	"net/http/httptest"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/Hunsin/beaver"
 )
 
 // regex of a log
@@ -38,6 +41,30 @@ func TestFile(t *testing.T) {
 	}
 }
 
+func TestReopenFile(t *testing.T) {
+	n := "temp.log"
+	l := New(nil).ReopenFile(n)
+	defer os.Remove(n)
+
+	s := "Hello world"
+	fmt.Fprint(l.out, s)
+	if _, ok := l.out.(*beaver.ReopenWriter); !ok {
+		t.Fatalf("ReopenFile failed. Output is %T, want *beaver.ReopenWriter", l.out)
+	}
+
+	// the ReopenWriter is closed when output changes
+	r := l.out.(*beaver.ReopenWriter)
+	l.Output(&bytes.Buffer{})
+	if _, err := fmt.Fprint(r, s); err == nil {
+		t.Error("ReopenFile failed, the file should be closed when output changes")
+	}
+
+	out, _ := ioutil.ReadFile(n)
+	if string(out) != s {
+		t.Errorf("ReopenFile failed. Got: %s, Want: %s", out, s)
+	}
+}
+
 func TestOutput(t *testing.T) {
 	b := bytes.Buffer{}
 	l := New(nil).Output(&b)
@@ -111,3 +138,28 @@ func TestListen(t *testing.T) {
 		t.Error("Listen failed. output:", s)
 	}
 }
+
+func TestListenContext(t *testing.T) {
+	b := bytes.Buffer{}
+	bl := beaver.NewLogger().Output(&b).Flags(0)
+	l := New(ioutil.Discard).Context(bl)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		beaver.FromContext(r.Context()).Info("handled")
+	})
+
+	r := httptest.NewRequest("GET", "/path", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	l.Listen(h).ServeHTTP(httptest.NewRecorder(), r)
+
+	want := "INFO : handled request_id=abc method=GET path=/path\n"
+	if b.String() != want {
+		t.Errorf("Listen failed: request-scoped logger not injected. Got: %q, Want: %q", b.String(), want)
+	}
+
+	// a random ID is generated if the header is absent
+	b.Reset()
+	l.Listen(h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/path", nil))
+	if !strings.HasPrefix(b.String(), "INFO : handled request_id=") || strings.Contains(b.String(), "request_id=abc") {
+		t.Errorf("Listen failed: request ID not generated. Got: %q", b.String())
+	}
+}
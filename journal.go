@@ -0,0 +1,136 @@
+package beaver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// the native socket of journald
+var journalSocket = "/run/systemd/journal/socket"
+
+// A JournalSink is a Sink which sends entries to journald through its
+// native protocol. Besides MESSAGE, PRIORITY and SYSLOG_IDENTIFIER, the
+// name of Logger is sent as LOGGER, the caller, if reported, as CODE_FILE,
+// CODE_LINE and CODE_FUNC, and each field as a journal field which key is
+// upper-cased with invalid characters replaced by '_'. An entry larger
+// than the maximum datagram size fails to send. It is safe for concurrent
+// use.
+type JournalSink struct {
+	id   string
+	mu   sync.Mutex
+	conn net.Conn
+	lv   int
+}
+
+// DialJournal connects to journald. The identifier is the
+// SYSLOG_IDENTIFIER of entries; if it's empty, the base name of the
+// program is applied. By default, the sink accepts all levels.
+func DialJournal(identifier string) (*JournalSink, error) {
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+
+	c, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return nil, err
+	}
+	return &JournalSink{id: identifier, conn: c, lv: Lall}, nil
+}
+
+// Level sets the levels of logs accepted by s.
+func (s *JournalSink) Level(lv int) *JournalSink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lv = lv
+	return s
+}
+
+// Enabled implements the Sink interface.
+func (s *JournalSink) Enabled(lv int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lv&lv != 0
+}
+
+// Log implements the Sink interface.
+func (s *JournalSink) Log(e *Entry) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", e.Message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(syslogSeverity(e.Level)))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", s.id)
+	if e.Name != "" {
+		writeJournalField(&b, "LOGGER", e.Name)
+	}
+	if e.File != "" {
+		writeJournalField(&b, "CODE_FILE", e.File)
+		writeJournalField(&b, "CODE_LINE", strconv.Itoa(e.Line))
+		if f := runtime.FuncForPC(e.pc); f != nil {
+			writeJournalField(&b, "CODE_FUNC", f.Name())
+		}
+	}
+
+	for i := 0; i < len(e.Fields); i += 2 {
+		k := journalKey(logfmtValue(e.Fields[i]))
+		if k == "" {
+			continue
+		}
+
+		v := "(MISSING)"
+		if i+1 < len(e.Fields) {
+			v = logfmtValue(e.Fields[i+1])
+		}
+		writeJournalField(&b, k, v)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.conn.Write(b.Bytes())
+	return err
+}
+
+// Close closes the connection.
+func (s *JournalSink) Close() error {
+	return s.conn.Close()
+}
+
+// writeJournalField writes k and v to b in the native protocol. A value
+// containing newlines is written in binary form with its length.
+func writeJournalField(b *bytes.Buffer, k, v string) {
+	b.WriteString(k)
+	if !strings.Contains(v, "\n") {
+		b.WriteString("=" + v + "\n")
+		return
+	}
+
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(v)))
+	b.WriteString(v + "\n")
+}
+
+// journalKey returns k in upper case with characters other than letters,
+// digits and '_' replaced by '_'. The leading underscores, reserved by
+// journald, are removed, and a leading digit is prefixed with 'F'.
+func journalKey(k string) string {
+	k = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, k)
+
+	k = strings.TrimLeft(k, "_")
+	if k != "" && k[0] >= '0' && k[0] <= '9' {
+		k = "F" + k
+	}
+	return k
+}
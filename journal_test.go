@@ -0,0 +1,67 @@
+//go:build !windows && !plan9 && !js
+// +build !windows,!plan9,!js
+
+package beaver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beaver")
+	if err != nil {
+		t.Fatal("ioutil.TempDir exits with error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "journal.sock")
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal("net.ListenPacket exits with error:", err)
+	}
+	defer pc.Close()
+
+	defer func(p string) { journalSocket = p }(journalSocket)
+	journalSocket = path
+
+	s, err := DialJournal("beaver")
+	if err != nil {
+		t.Fatal("DialJournal exits with error:", err)
+	}
+	defer s.Close()
+
+	NewLogger().Flags(log.Lshortfile).Sinks(s).Named("db").Warnw(message, "user.id", 42, "query", "a\nb")
+
+	b := make([]byte, 4096)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal("JournalSink failed: reading datagram:", err)
+	}
+
+	var multi bytes.Buffer
+	multi.WriteString("QUERY\n")
+	binary.Write(&multi, binary.LittleEndian, uint64(3))
+	multi.WriteString("a\nb\n")
+
+	got := b[:n]
+	for _, want := range [][]byte{
+		[]byte("MESSAGE=" + message + "\n"),
+		[]byte("PRIORITY=4\n"),
+		[]byte("SYSLOG_IDENTIFIER=beaver\n"),
+		[]byte("LOGGER=db\n"),
+		[]byte("CODE_FUNC=github.com/Hunsin/beaver.TestJournalSink\n"),
+		[]byte("USER_ID=42\n"),
+		multi.Bytes(),
+	} {
+		if !bytes.Contains(got, want) {
+			t.Errorf("JournalSink failed: field %q not found in %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package beaver
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// the bits available to custom levels
+const (
+	lcustomMin = Ltrace << 1
+	lcustomMax = 1 << 30
+	lcustom    = lcustomMax<<1 - lcustomMin
+)
+
+// A level is a custom level registered by RegisterLevel.
+type level struct {
+	name, tag string
+}
+
+var levels struct {
+	mu   sync.RWMutex
+	next int
+	m    map[int]level
+}
+
+// builtin names of levels
+var levelNames = map[int]string{
+	Lfatal: "fatal",
+	Lpanic: "panic",
+	Lerror: "error",
+	Lwarn:  "warn",
+	Linfo:  "info",
+	Ldebug: "debug",
+	Ltrace: "trace",
+}
+
+// RegisterLevel registers a custom level with name and tag, and returns
+// its bit which can be used in level masks and Logger.Log. The name is
+// case-insensitive and stored in lower case. The level is enabled in Lall
+// but not in masks defined before. An error is returned if the name is
+// empty, already used or reserved, contains whitespace or any of
+// ",+=*:" used by ParseLevel and SetLevels, or there are no bits
+// available.
+func RegisterLevel(name, tag string) (int, error) {
+	name = strings.ToLower(name)
+	if name == "" {
+		return 0, errors.New("beaver: empty level name")
+	}
+	if name == "all" || name == "none" {
+		return 0, errors.New("beaver: level name " + name + " is reserved")
+	}
+	if strings.ContainsAny(name, ",+=*:") || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return 0, errors.New("beaver: invalid level name " + strconv.Quote(name))
+	}
+
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+
+	if levelBit(name) != 0 {
+		return 0, errors.New("beaver: level " + name + " already registered")
+	}
+
+	if levels.next == 0 {
+		levels.next = lcustomMin
+		levels.m = make(map[int]level)
+	}
+	if levels.next > lcustomMax {
+		return 0, errors.New("beaver: no more custom levels available")
+	}
+
+	lv := levels.next
+	levels.next <<= 1
+	levels.m[lv] = level{name, tag}
+	return lv, nil
+}
+
+// levelBit returns the bit of level named name, or 0 if not found. The
+// caller must hold levels.mu.
+func levelBit(name string) int {
+	for lv, n := range levelNames {
+		if n == name {
+			return lv
+		}
+	}
+	for lv, c := range levels.m {
+		if c.name == name {
+			return lv
+		}
+	}
+	return 0
+}
+
+// customLevel returns the registered custom level lv.
+func customLevel(lv int) level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	return levels.m[lv]
+}
+
+// levelName returns the lower-case name of level lv, or an empty string
+// if lv is not a single known level.
+func levelName(lv int) string {
+	if n, ok := levelNames[lv]; ok {
+		return n
+	}
+	return customLevel(lv).name
+}
+
+// LevelEnv is the environment variable read by LogLevelFromEnv.
+const LevelEnv = "BEAVER_LOG_LEVEL"
+
+// builtin levels in order of severity
+var severity = []int{Lfatal, Lpanic, Lerror, Lwarn, Linfo, Ldebug, Ltrace}
+
+// ParseLevel parses s as a level mask. A single level name selects
+// that level and all the predefined levels more severe, so "warn" is
+// equivalent to Lfatal|Lpanic|Lerror|Lwarn. Names are case-insensitive.
+//
+// The s can also be a comma-separated list, in which a name selects that
+// level only, e.g. "error,debug" is Lerror|Ldebug. A name followed by "+"
+// always selects the levels more severe as well, and a name preceded by
+// "=" always selects that level only. The special names "all" and "none"
+// select all or no levels. Custom levels registered by RegisterLevel are
+// accepted, but they always select themselves only and can't be used
+// with "+".
+func ParseLevel(s string) (int, error) {
+	lv := 0
+	items := strings.Split(s, ",")
+	for _, item := range items {
+		name := strings.ToLower(strings.TrimSpace(item))
+		switch name {
+		case "all":
+			lv |= Lall
+			continue
+		case "none":
+			continue
+		}
+
+		plus, exact := strings.HasSuffix(name, "+"), strings.HasPrefix(name, "=")
+		name = strings.TrimPrefix(strings.TrimSuffix(name, "+"), "=")
+
+		levels.mu.RLock()
+		bit := levelBit(name)
+		levels.mu.RUnlock()
+
+		if bit == 0 || plus && exact {
+			return 0, errors.New("beaver: invalid level " + strconv.Quote(strings.TrimSpace(item)))
+		}
+		if bit&lcustom != 0 && plus {
+			return 0, errors.New("beaver: custom level " + strconv.Quote(name) + " can't be used with +")
+		}
+		if bit&lcustom != 0 || exact || !plus && len(items) > 1 {
+			lv |= bit
+			continue
+		}
+
+		for _, b := range severity {
+			lv |= b
+			if b == bit {
+				break
+			}
+		}
+	}
+	return lv, nil
+}
+
+// FormatLevel returns the string form of level mask lv, which can be
+// parsed by ParseLevel. The levels more severe than and including the
+// least severe one are presented by the name of it followed by "+".
+// Bits of unregistered levels are ignored.
+func FormatLevel(lv int) string {
+	if lv&Lall == Lall {
+		return "all"
+	}
+
+	var names []string
+	n := 0
+	for n < len(severity) && lv&severity[n] != 0 {
+		n++
+	}
+	if n > 1 {
+		names = append(names, levelName(severity[n-1])+"+")
+		for _, b := range severity[:n] {
+			lv &^= b
+		}
+	}
+
+	for b := 1; b <= lcustomMax; b <<= 1 {
+		if lv&b != 0 {
+			if name := levelName(b); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	switch {
+	case len(names) == 0:
+		return "none"
+	case len(names) == 1 && !strings.HasSuffix(names[0], "+"):
+		return "=" + names[0]
+	}
+	return strings.Join(names, ",")
+}
+
+// A levelFlag sets the level of a Logger as a flag.Value.
+type levelFlag struct {
+	l *Logger
+}
+
+func (f levelFlag) Set(s string) error {
+	lv, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+
+	f.l.Level(lv)
+	return nil
+}
+
+func (f levelFlag) String() string {
+	if f.l == nil {
+		return ""
+	}
+	return FormatLevel(f.l.ownLevel())
+}
+
+// LevelFlag returns a flag.Value which sets the level of l by the
+// syntax of ParseLevel. For example:
+//
+//	flag.Var(l.LevelFlag(), "log-level", "levels of logs, e.g. info+")
+func (l *Logger) LevelFlag() flag.Value {
+	return levelFlag{l}
+}
+
+// LevelFromEnv sets the level of l by the value of environment variable
+// key, in the syntax of ParseLevel. The level is unchanged if the variable
+// is not present or the value is invalid, in which case an error is
+// returned.
+func (l *Logger) LevelFromEnv(key string) error {
+	s, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	lv, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+
+	l.Level(lv)
+	return nil
+}
@@ -0,0 +1,87 @@
+package beaver
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// levelState is the JSON body of LevelHandler. The Level is the level
+// of default Logger and Loggers are the rules set by SetLevels.
+type levelState struct {
+	Level   *string           `json:"level,omitempty"`
+	Loggers map[string]string `json:"loggers"`
+}
+
+// levelMu serializes updates of LevelHandler
+var levelMu sync.Mutex
+
+// LevelHandler returns a http.Handler which reports and updates the level
+// of default Logger and the rules of named loggers. A GET request responses
+// with a JSON object like:
+//
+//	{"level":"info+","loggers":{"*":"info+","db":"debug+"}}
+//
+// where the levels are formatted by FormatLevel. A PUT request with a JSON
+// object in the same form updates the level of default Logger if "level"
+// presents, which is followed by its children unless their levels are
+// set, and replaces all rules by SetLevels if "loggers" presents. An
+// empty object in "loggers" removes all rules. The levels are parsed by
+// ParseLevel, and nothing is changed if any of them is invalid. The current
+// state is responded after the update. Other methods are not allowed.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(serveLevel)
+}
+
+func serveLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+	case "PUT":
+		if err := putLevel(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	lv := FormatLevel(stdLgr.ownLevel())
+	JSON(&levelState{&lv, levelRules.levels()}).Serve(w, http.StatusOK)
+}
+
+// putLevel applies the levels in the body of r.
+func putLevel(r *http.Request) error {
+	var s levelState
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		return err
+	}
+
+	lv := -1
+	if s.Level != nil {
+		var err error
+		if lv, err = ParseLevel(*s.Level); err != nil {
+			return err
+		}
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	if s.Loggers != nil {
+		var names, lvs []string
+		for name, l := range s.Loggers {
+			names = append(names, name)
+			lvs = append(lvs, l)
+		}
+		if err := setLevelRules(names, lvs); err != nil {
+			return err
+		}
+	}
+
+	if lv >= 0 {
+		stdLgr.Level(lv)
+	}
+	return nil
+}
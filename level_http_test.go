@@ -0,0 +1,90 @@
+package beaver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func doLevel(t *testing.T, method, body string) (*httptest.ResponseRecorder, levelState) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, "/log/level", strings.NewReader(body))
+	LevelHandler().ServeHTTP(w, r)
+
+	var s levelState
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+			t.Fatalf("LevelHandler failed: invalid JSON %s: %v", w.Body, err)
+		}
+	}
+	return w, s
+}
+
+func TestLevelHandler(t *testing.T) {
+	defer SetLevels("")
+	defer LogLevel(Lall)
+
+	LogLevel(Lerror | Lwarn)
+	SetLevels("db=debug")
+	child := With("k", 1)
+
+	w, s := doLevel(t, "GET", "")
+	if w.Code != http.StatusOK || *s.Level != "error,warn" || len(s.Loggers) != 1 || s.Loggers["db"] != "debug+" {
+		t.Errorf("LevelHandler GET failed. Got: %d %s", w.Code, w.Body)
+	}
+
+	w, s = doLevel(t, "PUT", `{"level":"info","loggers":{"http":"warn","*":"error,debug"}}`)
+	if w.Code != http.StatusOK || *s.Level != "info+" || len(s.Loggers) != 2 || s.Loggers["*"] != "error,debug" {
+		t.Errorf("LevelHandler PUT failed. Got: %d %s", w.Code, w.Body)
+	}
+
+	info, _ := ParseLevel("info")
+	warn, _ := ParseLevel("warn")
+	if lv := int(stdLgr.lv); lv != info {
+		t.Errorf("LevelHandler PUT failed: level of default Logger not set. Got: %d", lv)
+	}
+	if lv := child.ownLevel(); lv != info {
+		t.Errorf("LevelHandler PUT failed: level not followed by existing child. Got: %d", lv)
+	}
+	if lv := NewLogger().Named("http").level(); lv != warn {
+		t.Errorf("LevelHandler PUT failed: rules not set. Got: %d", lv)
+	}
+
+	// only level is changed
+	w, s = doLevel(t, "PUT", `{"level":"=debug"}`)
+	if w.Code != http.StatusOK || *s.Level != "=debug" || len(s.Loggers) != 2 {
+		t.Errorf("LevelHandler PUT failed. Got: %d %s", w.Code, w.Body)
+	}
+
+	// nothing is changed on error
+	for _, body := range []string{`{"level":"loud"}`, `{"level":"info","loggers":{"db":"loud"}}`, `not JSON`} {
+		if w, _ = doLevel(t, "PUT", body); w.Code != http.StatusBadRequest {
+			t.Errorf("LevelHandler PUT %s failed: status should be Bad Request. Got: %d", body, w.Code)
+		}
+	}
+	if _, s = doLevel(t, "GET", ""); *s.Level != "=debug" || len(s.Loggers) != 2 {
+		t.Errorf("LevelHandler PUT failed: levels should be unchanged on error. Got: %v %v", *s.Level, s.Loggers)
+	}
+
+	if w, _ = doLevel(t, "POST", "{}"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("LevelHandler POST failed: status should be Method Not Allowed. Got: %d", w.Code)
+	}
+
+	// updating while logging must be safe
+	LogOutput(ioutil.Discard)
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			Debug(message)
+			With("k", i).Named("db").Info(message)
+		}
+		close(done)
+	}()
+	for i := 0; i < 10; i++ {
+		doLevel(t, "PUT", `{"level":"info","loggers":{"db":"debug"}}`)
+	}
+	<-done
+}
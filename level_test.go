@@ -0,0 +1,199 @@
+package beaver
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+)
+
+// resetLevels unregisters the custom levels.
+func resetLevels() {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.next, levels.m = 0, nil
+}
+
+func TestRegisterLevel(t *testing.T) {
+	defer resetLevels()
+
+	audit, err := RegisterLevel("Audit", "AUDIT:")
+	if err != nil {
+		t.Fatal("RegisterLevel failed:", err)
+	}
+	if audit&lcustom == 0 || audit&(audit-1) != 0 || Lall&audit == 0 {
+		t.Errorf("RegisterLevel failed: invalid bit %d", audit)
+	}
+
+	if _, err = RegisterLevel("audit", "AUDIT:"); err == nil {
+		t.Error("RegisterLevel failed: duplicated name should cause an error")
+	}
+	if _, err = RegisterLevel("debug", "DEBUG:"); err == nil {
+		t.Error("RegisterLevel failed: builtin name should cause an error")
+	}
+	if _, err = RegisterLevel("", ""); err == nil {
+		t.Error("RegisterLevel failed: empty name should cause an error")
+	}
+	for _, name := range []string{"all", "None", "a,b", "x+", "=x", "*", "db:x", "a b", "tab\t"} {
+		if _, err = RegisterLevel(name, ""); err == nil {
+			t.Errorf("RegisterLevel failed: name %q should cause an error", name)
+		}
+	}
+
+	notice, _ := RegisterLevel("notice", "NOTE :")
+	if notice == audit {
+		t.Error("RegisterLevel failed: bits of levels must be different")
+	}
+
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w)
+
+	l.Logf(audit, "%s", message)
+	if !reg.Match(w.Bytes()) || !bytes.Contains(w.Bytes(), []byte("AUDIT: "+message)) {
+		t.Errorf("Logger.Log failed at custom level. Got: %v", w)
+	}
+	w.Reset()
+
+	// custom level must be enabled explicitly in masks
+	l.Level(Linfo).Log(audit, message)
+	if w.Len() != 0 {
+		t.Errorf("Logger.Log failed: level not respected. Got: %v", w)
+	}
+	l.Level(Linfo|audit).Log(audit, message)
+	if w.Len() == 0 {
+		t.Error("Logger.Log failed: custom level enabled but not written")
+	}
+	w.Reset()
+
+	// empty LTag omits tags of custom levels as well
+	l.Tags(LTag{}).Log(audit, message)
+	r := regexp.MustCompile("^" + regDateTime + message + "\n$")
+	if !r.Match(w.Bytes()) {
+		t.Errorf("Logger.Log failed: tag should be omitted. Got: %v", w)
+	}
+	w.Reset()
+
+	l.Formatter(JSONFormatter{}).Logw(audit, message, "user", 42)
+	out := map[string]interface{}{}
+	json.Unmarshal(w.Bytes(), &out)
+	if out["level"] != "audit" {
+		t.Errorf("JSONFormatter failed: level name of custom level not match. Got: %s", w)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	w := new(bytes.Buffer)
+
+	// test default Logger
+	LogOutput(w)
+	LogLevel(Ltrace)
+	defer LogLevel(Lall)
+	Debug(message)
+	Trace(message)
+
+	r := regexp.MustCompile("^" + regDateTime + "TRACE: " + message + "\n$")
+	if !r.Match(w.Bytes()) {
+		t.Errorf("Default Logger.Trace failed. Got: %v", w)
+	}
+
+	w.Reset()
+
+	// test new Logger
+	l := NewLogger().Output(w).Level(Ltrace).Tags(BracketLogTag)
+	l.Tracew(message, "k", "v")
+
+	r = regexp.MustCompile("^" + regDateTime + `\[TRACE\] ` + message + " k=v\n$")
+	if !r.Match(w.Bytes()) {
+		t.Errorf("New Logger.Tracew failed. Got: %v", w)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := []struct {
+		s    string
+		want int
+	}{
+		{"info", Lall &^ Ldebug &^ Ltrace &^ lcustom},
+		{"=info", Linfo},
+		{"WARN+", Lfatal | Lpanic | Lerror | Lwarn},
+		{"warn", Lfatal | Lpanic | Lerror | Lwarn},
+		{"error, warn", Lerror | Lwarn},
+		{"debug+", Lall &^ Ltrace &^ lcustom},
+		{"fatal+,trace", Lfatal | Ltrace},
+		{"all", Lall},
+		{"none", 0},
+	}
+
+	for _, c := range cases {
+		lv, err := ParseLevel(c.s)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) failed: %v", c.s, err)
+		}
+		if lv != c.want {
+			t.Errorf("ParseLevel(%q) failed. Got: %d, Want: %d", c.s, lv, c.want)
+		}
+
+		// the result of FormatLevel must be parsed to the same level
+		if lv2, err := ParseLevel(FormatLevel(lv)); err != nil || lv2 != lv {
+			t.Errorf("FormatLevel(%d) failed. Got: %s, parsed: %d, %v", lv, FormatLevel(lv), lv2, err)
+		}
+	}
+
+	for _, s := range []string{"", "verbose", "info,", "info++", "=info+"} {
+		if _, err := ParseLevel(s); err == nil {
+			t.Errorf("ParseLevel(%q) failed: invalid input should cause an error", s)
+		}
+	}
+
+	if s := FormatLevel(Lerror | Lwarn | Lfatal | Lpanic | Ldebug); s != "warn+,debug" {
+		t.Errorf("FormatLevel failed. Got: %s, Want: warn+,debug", s)
+	}
+	if s := FormatLevel(Lerror); s != "=error" {
+		t.Errorf("FormatLevel failed. Got: %s, Want: =error", s)
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	l := NewLogger()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(l.LevelFlag(), "log", "log level")
+
+	if err := fs.Parse([]string{"-log", "info"}); err != nil {
+		t.Fatal("LevelFlag failed:", err)
+	}
+	if l.lv != Lfatal|Lpanic|Lerror|Lwarn|Linfo {
+		t.Errorf("LevelFlag failed: level not set. Got: %d", l.lv)
+	}
+	if s := fs.Lookup("log").Value.String(); s != "info+" {
+		t.Errorf("LevelFlag failed. Got: %s, Want: info+", s)
+	}
+
+	fs.SetOutput(ioutil.Discard)
+	if err := fs.Parse([]string{"-log", "loud"}); err == nil {
+		t.Error("LevelFlag failed: invalid level should cause an error")
+	}
+}
+
+func TestLevelFromEnv(t *testing.T) {
+	l := NewLogger()
+	key := "BEAVER_TEST_LEVEL"
+
+	os.Unsetenv(key)
+	if err := l.LevelFromEnv(key); err != nil || l.lv != Lall {
+		t.Errorf("Logger.LevelFromEnv failed: level should be unchanged if not set. Got: %d, %v", l.lv, err)
+	}
+
+	os.Setenv(key, "error")
+	defer os.Unsetenv(key)
+	if err := l.LevelFromEnv(key); err != nil || l.lv != Lfatal|Lpanic|Lerror {
+		t.Errorf("Logger.LevelFromEnv failed. Got: %d, %v", l.lv, err)
+	}
+
+	os.Setenv(key, "noisy")
+	if err := l.LevelFromEnv(key); err == nil || l.lv != Lfatal|Lpanic|Lerror {
+		t.Errorf("Logger.LevelFromEnv failed: invalid level. Got: %d, %v", l.lv, err)
+	}
+}
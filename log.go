@@ -1,10 +1,16 @@
 package beaver
 
 import (
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // The levels define whether a Logger should generate logs in different level
@@ -14,153 +20,673 @@ const (
 	Lwarn
 	Linfo
 	Ldebug
+	Lpanic
+	Ltrace
 
-	Lall = Lfatal | Lerror | Lwarn | Linfo | Ldebug
+	// Lall includes the levels registered by RegisterLevel
+	Lall = Lfatal | Lpanic | Lerror | Lwarn | Linfo | Ldebug | Ltrace | lcustom
 )
 
 // A LTag is a set of strings which identify the severity level
 // of each log
 type LTag struct {
-	Fatal, Error, Warn, Info, Debug string
+	Fatal, Panic, Error, Warn, Info, Debug, Trace string
 }
 
 var (
 
 	// DefaultLogTag is a LTag with default strings
-	DefaultLogTag = LTag{"FATAL:", "ERROR:", "WARN :", "INFO :", "DEBUG:"}
+	DefaultLogTag = LTag{"FATAL:", "PANIC:", "ERROR:", "WARN :", "INFO :", "DEBUG:", "TRACE:"}
 
 	// BracketLogTag is a LTag with "[...]" style
-	BracketLogTag = LTag{"[FATAL]", "[ERROR]", "[WARN ]", "[INFO ]", "[DEBUG]"}
+	BracketLogTag = LTag{"[FATAL]", "[PANIC]", "[ERROR]", "[WARN ]", "[INFO ]", "[DEBUG]", "[TRACE]"}
 )
 
-// A Logger wraps a pointer to log.Logger and additional fields.
-// It prints level tag and the log if severity level meets its
-// configuration.
+// tag returns the string of t which identifies level lv. The tag of
+// a custom level is the one registered, unless all tags of t are empty.
+func (t LTag) tag(lv int) string {
+	switch lv {
+	case Lfatal:
+		return t.Fatal
+	case Lpanic:
+		return t.Panic
+	case Lerror:
+		return t.Error
+	case Lwarn:
+		return t.Warn
+	case Linfo:
+		return t.Info
+	case Ldebug:
+		return t.Debug
+	case Ltrace:
+		return t.Trace
+	}
+
+	if t == (LTag{}) {
+		return ""
+	}
+	return customLevel(lv).tag
+}
+
+// A Logger prints level tag and the log if severity level meets its
+// configuration. The logs are encoded by a Formatter and written to
+// an io.Writer; each log is written with a single call to the Writer's
+// Write method. A Logger can be used and reconfigured simultaneously
+// from multiple goroutines.
 type Logger struct {
-	o  *log.Logger
-	lv int
+	out  *output
+	name string
+	kv   []interface{}
+	lv   int32        // accessed atomically; lvInherit to follow parent
+	up   *Logger      // the parent, if created by With or Named
+	mu   sync.Mutex   // serializes updates of cfg
+	cfg  atomic.Value // *logConfig, copied on write
+}
+
+// A logConfig holds the configurations of a Logger which are replaced
+// as a whole on updates.
+type logConfig struct {
 	t  LTag
+	ex func(int)
+	sp map[int]*sampler // by level bit; read only
+	dd *dedup
+}
+
+// config returns the current configurations of l.
+func (l *Logger) config() *logConfig {
+	return l.cfg.Load().(*logConfig)
+}
+
+// update applies fn to a copy of the configurations of l, and then
+// replaces them with the copy.
+func (l *Logger) update(fn func(c *logConfig)) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c := *l.config()
+	fn(&c)
+	l.cfg.Store(&c)
+	return l
 }
 
-// write calls l.o.Output to print tag and v to output
-func (l *Logger) write(tag string, v ...interface{}) {
-	if tag != "" {
-		v = append([]interface{}{tag}, v...)
+// An output holds the destination of logs and the configurations
+// shared between a Logger and its children. It serializes access to
+// the Writer.
+type output struct {
+	mu  sync.Mutex   // serializes writes and updates of cfg
+	cfg atomic.Value // *outConfig, copied on write
+}
+
+// An outConfig holds the configurations of an output.
+type outConfig struct {
+	w      io.Writer
+	f      Formatter
+	h      entryHandler
+	sinks  []Sink
+	prefix string
+	flag   int
+	color  bool // whether w is a terminal which colors are written to
+}
+
+// newOutput returns an output with given configurations.
+func newOutput(c outConfig) *output {
+	o := &output{}
+	o.cfg.Store(&c)
+	return o
+}
+
+// config returns the current configurations of o.
+func (o *output) config() *outConfig {
+	return o.cfg.Load().(*outConfig)
+}
+
+// update applies fn to a copy of the configurations of o, and then
+// replaces them with the copy.
+func (o *output) update(fn func(c *outConfig)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	c := *o.config()
+	fn(&c)
+	o.cfg.Store(&c)
+}
+
+// An entryHandler receives the entries of an output, instead of its
+// Formatter and Writer. The caller of each entry is always reported.
+type entryHandler interface {
+	handle(e *Entry)
+}
+
+// write encodes e by the Formatter and writes the result to the Writer
+// of o. The depth is the number of stack frames to ascend, from the
+// caller of o.write, to reach the caller reported in e. The caller is
+// not looked up if e.File is already set.
+func (o *output) write(e *Entry, depth int) {
+	c := o.config()
+	e.Prefix, e.Flags = c.prefix, c.flag
+	if e.File == "" && (e.Flags&(log.Lshortfile|log.Llongfile) != 0 || c.h != nil || c.sinks != nil) {
+		var ok bool
+		if e.pc, e.File, e.Line, ok = runtime.Caller(depth + 1); !ok {
+			e.File = "???"
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if c.h != nil {
+		c.h.handle(e)
+		return
+	}
+
+	if c.sinks != nil {
+		for _, s := range c.sinks {
+			if s.Enabled(e.Level) {
+				s.Log(e)
+			}
+		}
+		return
+	}
+
+	if !c.color {
+		e.Tag = stripColor(e.Tag)
+	}
+	if b, err := c.f.Format(e); err == nil {
+		c.w.Write(b)
+	}
+}
+
+// write builds an Entry of level lv with msg, the fields of l and kv,
+// and writes it to output, unless it's dropped by sampling or
+// deduplication
+func (l *Logger) write(lv int, msg string, kv []interface{}) {
+	c := l.config()
+	e := &Entry{
+		Time:    time.Now(),
+		Level:   lv,
+		Tag:     c.t.tag(lv),
+		Name:    l.name,
+		Message: strings.TrimSuffix(msg, "\n"),
 	}
-	l.o.Output(3, fmt.Sprintln(v...))
+
+	ok, rep := c.filter(e, l.out)
+	if !ok {
+		return
+	}
+	if rep != nil {
+		l.out.write(rep, 2)
+	}
+
+	e.Fields = append(l.kv[:len(l.kv):len(l.kv)], kv...)
+	l.out.write(e, 2)
 }
 
-// Fatal calls os.Exit(1) after writes fatal tag and v to output
+// Fatal calls l.Exit(1) after writes fatal tag and v to output
 func (l *Logger) Fatal(v ...interface{}) {
-	if l.lv&Lfatal != 0 {
-		l.write(l.t.Fatal, v...)
+	if l.level()&Lfatal != 0 {
+		l.write(Lfatal, fmt.Sprintln(v...), nil)
+	}
+	l.Exit(1)
+}
+
+// Panic writes panic tag and v to output, and then panics with
+// the message
+func (l *Logger) Panic(v ...interface{}) {
+	s := fmt.Sprintln(v...)
+	if l.level()&Lpanic != 0 {
+		l.write(Lpanic, s, nil)
 	}
-	os.Exit(1)
+	panic(strings.TrimSuffix(s, "\n"))
 }
 
 // Error writes error tag and v to output
 func (l *Logger) Error(v ...interface{}) {
-	if l.lv&Lerror != 0 {
-		l.write(l.t.Error, v...)
+	if l.level()&Lerror != 0 {
+		l.write(Lerror, fmt.Sprintln(v...), nil)
 	}
 }
 
 // Warn writes warn tag and v to output
 func (l *Logger) Warn(v ...interface{}) {
-	if l.lv&Lwarn != 0 {
-		l.write(l.t.Warn, v...)
+	if l.level()&Lwarn != 0 {
+		l.write(Lwarn, fmt.Sprintln(v...), nil)
 	}
 }
 
 // Info writes info tag and v to output
 func (l *Logger) Info(v ...interface{}) {
-	if l.lv&Linfo != 0 {
-		l.write(l.t.Info, v...)
+	if l.level()&Linfo != 0 {
+		l.write(Linfo, fmt.Sprintln(v...), nil)
 	}
 }
 
 // Debug writes debug tag and v to output
 func (l *Logger) Debug(v ...interface{}) {
-	if l.lv&Ldebug != 0 {
-		l.write(l.t.Debug, v...)
+	if l.level()&Ldebug != 0 {
+		l.write(Ldebug, fmt.Sprintln(v...), nil)
+	}
+}
+
+// Trace writes trace tag and v to output
+func (l *Logger) Trace(v ...interface{}) {
+	if l.level()&Ltrace != 0 {
+		l.write(Ltrace, fmt.Sprintln(v...), nil)
 	}
 }
 
+// Fatalf calls l.Exit(1) after writes fatal tag and formatted
+// string to output. Arguments are handled in the manner of fmt.Printf
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	if l.level()&Lfatal != 0 {
+		l.write(Lfatal, fmt.Sprintf(format, v...), nil)
+	}
+	l.Exit(1)
+}
+
+// Panicf writes panic tag and formatted string to output, and then
+// panics with the string
+func (l *Logger) Panicf(format string, v ...interface{}) {
+	s := fmt.Sprintf(format, v...)
+	if l.level()&Lpanic != 0 {
+		l.write(Lpanic, s, nil)
+	}
+	panic(s)
+}
+
+// Errorf writes error tag and formatted string to output
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	if l.level()&Lerror != 0 {
+		l.write(Lerror, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Warnf writes warn tag and formatted string to output
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	if l.level()&Lwarn != 0 {
+		l.write(Lwarn, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Infof writes info tag and formatted string to output
+func (l *Logger) Infof(format string, v ...interface{}) {
+	if l.level()&Linfo != 0 {
+		l.write(Linfo, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Debugf writes debug tag and formatted string to output
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	if l.level()&Ldebug != 0 {
+		l.write(Ldebug, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Tracef writes trace tag and formatted string to output
+func (l *Logger) Tracef(format string, v ...interface{}) {
+	if l.level()&Ltrace != 0 {
+		l.write(Ltrace, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Fatalw calls l.Exit(1) after writes fatal tag, msg and key-value
+// pairs kv to output
+func (l *Logger) Fatalw(msg string, kv ...interface{}) {
+	if l.level()&Lfatal != 0 {
+		l.write(Lfatal, msg, kv)
+	}
+	l.Exit(1)
+}
+
+// Panicw writes panic tag, msg and key-value pairs kv to output, and
+// then panics with msg
+func (l *Logger) Panicw(msg string, kv ...interface{}) {
+	if l.level()&Lpanic != 0 {
+		l.write(Lpanic, msg, kv)
+	}
+	panic(msg)
+}
+
+// Errorw writes error tag, msg and key-value pairs kv to output
+func (l *Logger) Errorw(msg string, kv ...interface{}) {
+	if l.level()&Lerror != 0 {
+		l.write(Lerror, msg, kv)
+	}
+}
+
+// Warnw writes warn tag, msg and key-value pairs kv to output
+func (l *Logger) Warnw(msg string, kv ...interface{}) {
+	if l.level()&Lwarn != 0 {
+		l.write(Lwarn, msg, kv)
+	}
+}
+
+// Infow writes info tag, msg and key-value pairs kv to output
+func (l *Logger) Infow(msg string, kv ...interface{}) {
+	if l.level()&Linfo != 0 {
+		l.write(Linfo, msg, kv)
+	}
+}
+
+// Debugw writes debug tag, msg and key-value pairs kv to output
+func (l *Logger) Debugw(msg string, kv ...interface{}) {
+	if l.level()&Ldebug != 0 {
+		l.write(Ldebug, msg, kv)
+	}
+}
+
+// Tracew writes trace tag, msg and key-value pairs kv to output
+func (l *Logger) Tracew(msg string, kv ...interface{}) {
+	if l.level()&Ltrace != 0 {
+		l.write(Ltrace, msg, kv)
+	}
+}
+
+// Log writes the tag of level lv and v to output. The lv is either
+// one of the predefined levels or a custom level registered by
+// RegisterLevel. Unlike Fatal and Panic, it never exits nor panics.
+func (l *Logger) Log(lv int, v ...interface{}) {
+	if l.level()&lv != 0 {
+		l.write(lv, fmt.Sprintln(v...), nil)
+	}
+}
+
+// Logf writes the tag of level lv and formatted string to output
+func (l *Logger) Logf(lv int, format string, v ...interface{}) {
+	if l.level()&lv != 0 {
+		l.write(lv, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Logw writes the tag of level lv, msg and key-value pairs kv to output
+func (l *Logger) Logw(lv int, msg string, kv ...interface{}) {
+	if l.level()&lv != 0 {
+		l.write(lv, msg, kv)
+	}
+}
+
+// With returns a child Logger which appends key-value pairs kv to every
+// log, after the ones bound to l. The child shares the output, formatter,
+// flags and prefix with l; the name, tags and exit function are copied.
+// The level of the child follows the one of l, until it's set by Level.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	c := newLogger(l.out, l.name, append(l.kv[:len(l.kv):len(l.kv)], kv...),
+		lvInherit, *l.config())
+	c.up = l
+	return c
+}
+
+// Exit flushes the repeats suppressed by Dedup, runs the handlers
+// registered by RegisterExitHandler and then calls the exit function of
+// the Logger with code. It is called by Fatal, Fatalf and Fatalw.
+func (l *Logger) Exit(code int) {
+	l.Flush()
+	runExitHandlers()
+	if ex := l.config().ex; ex != nil {
+		ex(code)
+		return
+	}
+	os.Exit(code)
+}
+
+// ExitFunc sets the function called by l.Exit, which is os.Exit by
+// default. If f doesn't terminate the program, the Fatal methods return
+// after calling it. A nil f restores the default.
+func (l *Logger) ExitFunc(f func(int)) *Logger {
+	return l.update(func(c *logConfig) { c.ex = f })
+}
+
 // Flags sets the flags of the Logger. The flag follows the
 // standard package "log"
 func (l *Logger) Flags(f int) *Logger {
-	l.o.SetFlags(f)
+	l.out.update(func(c *outConfig) { c.flag = f })
+	return l
+}
+
+// Formatter sets the Formatter of the Logger. The f must not be nil
+func (l *Logger) Formatter(f Formatter) *Logger {
+	if f == nil {
+		panic("A nil Formatter can not be used")
+	}
+
+	l.out.update(func(c *outConfig) { c.f = f })
 	return l
 }
 
-// Level sets the level of the Logger
+// Level sets the level of the Logger, which no longer follows the one of
+// its parent. It is safe to call Level while logging
 func (l *Logger) Level(lv int) *Logger {
-	l.lv = lv
+	atomic.StoreInt32(&l.lv, int32(lv))
 	return l
 }
 
 // Output sets the output destination of the Logger. The out
-// must not be nil
+// must not be nil. The colors of tags, e.g. ColorLogTag, are removed
+// unless out is a terminal and the environment variable NO_COLOR is
+// empty
 func (l *Logger) Output(out io.Writer) *Logger {
 	if out == nil {
 		panic("A nil pointer can not be used as output")
 	}
 
-	l.o.SetOutput(out)
+	color := colorable(out)
+	l.out.update(func(c *outConfig) { c.w, c.color = out, color })
 	return l
 }
 
 // Prefix sets the prefix of of the Logger
 func (l *Logger) Prefix(p string) *Logger {
-	l.o.SetPrefix(p)
+	l.out.update(func(c *outConfig) { c.prefix = p })
+	return l
+}
+
+// Sinks sets the sinks of the Logger, which receive logs instead of
+// the output destination. Calling Sinks with no argument restores the
+// output destination. The sinks are shared with the children of l.
+func (l *Logger) Sinks(s ...Sink) *Logger {
+	var sinks []Sink
+	if len(s) > 0 {
+		sinks = append(sinks, s...)
+	}
+
+	l.out.update(func(c *outConfig) { c.sinks = sinks })
 	return l
 }
 
 // Tags sets the tags of the Logger. To omit the tag of logs,
-// simply use l.Tags(LTag{})
+// simply use l.Tags(LTag{}). Colored tags are written only to
+// terminals
 func (l *Logger) Tags(t LTag) *Logger {
-	l.t = t
-	return l
+	return l.update(func(c *logConfig) { c.t = t })
 }
 
 var stdLgr = NewLogger()
 
 // Fatal calls default Logger.Fatal
 func Fatal(v ...interface{}) {
-	if stdLgr.lv&Lfatal != 0 {
-		stdLgr.write(stdLgr.t.Fatal, v...)
+	if stdLgr.level()&Lfatal != 0 {
+		stdLgr.write(Lfatal, fmt.Sprintln(v...), nil)
+	}
+	stdLgr.Exit(1)
+}
+
+// Panic calls default Logger.Panic
+func Panic(v ...interface{}) {
+	s := fmt.Sprintln(v...)
+	if stdLgr.level()&Lpanic != 0 {
+		stdLgr.write(Lpanic, s, nil)
 	}
-	os.Exit(1)
+	panic(strings.TrimSuffix(s, "\n"))
 }
 
 // Error calls default Logger.Error
 func Error(v ...interface{}) {
-	if stdLgr.lv&Lerror != 0 {
-		stdLgr.write(stdLgr.t.Error, v...)
+	if stdLgr.level()&Lerror != 0 {
+		stdLgr.write(Lerror, fmt.Sprintln(v...), nil)
 	}
 }
 
 // Warn calls default Logger.Warn
 func Warn(v ...interface{}) {
-	if stdLgr.lv&Lwarn != 0 {
-		stdLgr.write(stdLgr.t.Warn, v...)
+	if stdLgr.level()&Lwarn != 0 {
+		stdLgr.write(Lwarn, fmt.Sprintln(v...), nil)
 	}
 }
 
 // Info calls default Logger.Info
 func Info(v ...interface{}) {
-	if stdLgr.lv&Linfo != 0 {
-		stdLgr.write(stdLgr.t.Info, v...)
+	if stdLgr.level()&Linfo != 0 {
+		stdLgr.write(Linfo, fmt.Sprintln(v...), nil)
 	}
 }
 
 // Debug calls default Logger.Debug
 func Debug(v ...interface{}) {
-	if stdLgr.lv&Ldebug != 0 {
-		stdLgr.write(stdLgr.t.Debug, v...)
+	if stdLgr.level()&Ldebug != 0 {
+		stdLgr.write(Ldebug, fmt.Sprintln(v...), nil)
+	}
+}
+
+// Trace calls default Logger.Trace
+func Trace(v ...interface{}) {
+	if stdLgr.level()&Ltrace != 0 {
+		stdLgr.write(Ltrace, fmt.Sprintln(v...), nil)
+	}
+}
+
+// Fatalf calls default Logger.Fatalf
+func Fatalf(format string, v ...interface{}) {
+	if stdLgr.level()&Lfatal != 0 {
+		stdLgr.write(Lfatal, fmt.Sprintf(format, v...), nil)
+	}
+	stdLgr.Exit(1)
+}
+
+// Panicf calls default Logger.Panicf
+func Panicf(format string, v ...interface{}) {
+	s := fmt.Sprintf(format, v...)
+	if stdLgr.level()&Lpanic != 0 {
+		stdLgr.write(Lpanic, s, nil)
+	}
+	panic(s)
+}
+
+// Errorf calls default Logger.Errorf
+func Errorf(format string, v ...interface{}) {
+	if stdLgr.level()&Lerror != 0 {
+		stdLgr.write(Lerror, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Warnf calls default Logger.Warnf
+func Warnf(format string, v ...interface{}) {
+	if stdLgr.level()&Lwarn != 0 {
+		stdLgr.write(Lwarn, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Infof calls default Logger.Infof
+func Infof(format string, v ...interface{}) {
+	if stdLgr.level()&Linfo != 0 {
+		stdLgr.write(Linfo, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Debugf calls default Logger.Debugf
+func Debugf(format string, v ...interface{}) {
+	if stdLgr.level()&Ldebug != 0 {
+		stdLgr.write(Ldebug, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Tracef calls default Logger.Tracef
+func Tracef(format string, v ...interface{}) {
+	if stdLgr.level()&Ltrace != 0 {
+		stdLgr.write(Ltrace, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Fatalw calls default Logger.Fatalw
+func Fatalw(msg string, kv ...interface{}) {
+	if stdLgr.level()&Lfatal != 0 {
+		stdLgr.write(Lfatal, msg, kv)
+	}
+	stdLgr.Exit(1)
+}
+
+// Panicw calls default Logger.Panicw
+func Panicw(msg string, kv ...interface{}) {
+	if stdLgr.level()&Lpanic != 0 {
+		stdLgr.write(Lpanic, msg, kv)
+	}
+	panic(msg)
+}
+
+// Errorw calls default Logger.Errorw
+func Errorw(msg string, kv ...interface{}) {
+	if stdLgr.level()&Lerror != 0 {
+		stdLgr.write(Lerror, msg, kv)
+	}
+}
+
+// Warnw calls default Logger.Warnw
+func Warnw(msg string, kv ...interface{}) {
+	if stdLgr.level()&Lwarn != 0 {
+		stdLgr.write(Lwarn, msg, kv)
+	}
+}
+
+// Infow calls default Logger.Infow
+func Infow(msg string, kv ...interface{}) {
+	if stdLgr.level()&Linfo != 0 {
+		stdLgr.write(Linfo, msg, kv)
+	}
+}
+
+// Debugw calls default Logger.Debugw
+func Debugw(msg string, kv ...interface{}) {
+	if stdLgr.level()&Ldebug != 0 {
+		stdLgr.write(Ldebug, msg, kv)
+	}
+}
+
+// Tracew calls default Logger.Tracew
+func Tracew(msg string, kv ...interface{}) {
+	if stdLgr.level()&Ltrace != 0 {
+		stdLgr.write(Ltrace, msg, kv)
+	}
+}
+
+// Log calls default Logger.Log
+func Log(lv int, v ...interface{}) {
+	if stdLgr.level()&lv != 0 {
+		stdLgr.write(lv, fmt.Sprintln(v...), nil)
+	}
+}
+
+// Logf calls default Logger.Logf
+func Logf(lv int, format string, v ...interface{}) {
+	if stdLgr.level()&lv != 0 {
+		stdLgr.write(lv, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Logw calls default Logger.Logw
+func Logw(lv int, msg string, kv ...interface{}) {
+	if stdLgr.level()&lv != 0 {
+		stdLgr.write(lv, msg, kv)
 	}
 }
 
+// With calls default Logger.With
+func With(kv ...interface{}) *Logger {
+	return stdLgr.With(kv...)
+}
+
+// LogExitFunc sets the exit function of default Logger
+func LogExitFunc(f func(int)) *Logger {
+	return stdLgr.ExitFunc(f)
+}
+
 // LogFlags sets the flags of default Logger
 func LogFlags(f int) *Logger {
 	return stdLgr.Flags(f)
@@ -171,6 +697,23 @@ func LogLevel(lv int) *Logger {
 	return stdLgr.Level(lv)
 }
 
+// LogFormatter sets the Formatter of default Logger
+func LogFormatter(f Formatter) *Logger {
+	return stdLgr.Formatter(f)
+}
+
+// LogLevelFlag returns a flag.Value which sets the level of default
+// Logger
+func LogLevelFlag() flag.Value {
+	return stdLgr.LevelFlag()
+}
+
+// LogLevelFromEnv sets the level of default Logger by the environment
+// variable BEAVER_LOG_LEVEL
+func LogLevelFromEnv() error {
+	return stdLgr.LevelFromEnv(LevelEnv)
+}
+
 // LogOutput sets the output destination of default Logger
 func LogOutput(out io.Writer) *Logger {
 	return stdLgr.Output(out)
@@ -181,17 +724,43 @@ func LogPrefix(p string) *Logger {
 	return stdLgr.Prefix(p)
 }
 
+// LogDedup sets the deduplication of default Logger
+func LogDedup(d time.Duration) *Logger {
+	return stdLgr.Dedup(d)
+}
+
+// LogSample sets the sampling of default Logger
+func LogSample(lv, n, m int, tick time.Duration) *Logger {
+	return stdLgr.Sample(lv, n, m, tick)
+}
+
+// LogSinks sets the sinks of default Logger
+func LogSinks(s ...Sink) *Logger {
+	return stdLgr.Sinks(s...)
+}
+
 // LogTags sets the tags of default Logger
 func LogTags(t LTag) *Logger {
 	return stdLgr.Tags(t)
 }
 
 // NewLogger returns a new Logger. By default, it logs at all
-// level and the output destination is standard output
+// level with TextFormatter, and the output destination is standard
+// output
 func NewLogger() *Logger {
-	return &Logger{
-		lv: Lall,
-		o:  log.New(os.Stdout, "", log.LstdFlags),
-		t:  DefaultLogTag,
-	}
+	out := newOutput(outConfig{
+		w:     os.Stdout,
+		f:     TextFormatter{},
+		flag:  log.LstdFlags,
+		color: colorable(os.Stdout),
+	})
+	return newLogger(out, "", nil, Lall, logConfig{t: DefaultLogTag})
+}
+
+// newLogger returns a Logger with given output, name, fields, level and
+// configurations.
+func newLogger(out *output, name string, kv []interface{}, lv int, c logConfig) *Logger {
+	l := &Logger{out: out, name: name, kv: kv, lv: int32(lv)}
+	l.cfg.Store(&c)
+	return l
 }
@@ -2,10 +2,14 @@ package beaver
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"os/exec"
 	"regexp"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -244,3 +248,174 @@ func TestTags(t *testing.T) {
 		t.Errorf("Default Logger.Tags failed. Got: %v", w)
 	}
 }
+
+func TestFormatted(t *testing.T) {
+	w := new(bytes.Buffer)
+	r := regexp.MustCompile("^log_test.go:[0-9]+: " + regTag + "Logging 2 messages\n$")
+
+	// test default Logger
+	LogOutput(w)
+	LogLevel(Lall)
+	LogFlags(log.Lshortfile)
+	defer LogFlags(log.LstdFlags)
+
+	fns := []func(string, ...interface{}){Errorf, Warnf, Infof, Debugf}
+	for _, fn := range fns {
+		fn("Logging %d %s", 2, "messages")
+		if !r.Match(w.Bytes()) {
+			t.Errorf("Default Logger formatted output failed. Got: %v", w)
+		}
+		w.Reset()
+	}
+
+	// test new Logger; caller must be reported
+	l := NewLogger().Output(w).Flags(log.Lshortfile)
+	_, _, line, _ := runtime.Caller(0)
+	l.Warnf("Logging %d %s", 2, "messages")
+
+	want := fmt.Sprintf("log_test.go:%d: ", line+1)
+	if !r.Match(w.Bytes()) || !strings.HasPrefix(w.String(), want) {
+		t.Errorf("New Logger formatted output failed. Got: %v, Want prefix: %s", w, want)
+	}
+}
+
+func TestStructured(t *testing.T) {
+	w := new(bytes.Buffer)
+
+	// test default Logger
+	LogOutput(w)
+	LogLevel(Lall)
+	Infow(message, "user", 42, "path", "/a b")
+
+	r := regexp.MustCompile("^" + regDateTime + regTag + message + ` user=42 path="/a b"` + "\n$")
+	if !r.Match(w.Bytes()) {
+		t.Errorf("Default Logger.Infow failed. Got: %v", w)
+	}
+
+	w.Reset()
+
+	// test child Logger; fields of parent must not be changed
+	l := NewLogger().Output(w).With("app", "beaver")
+	c := l.With("user", 42)
+
+	c.Error(message)
+	r = regexp.MustCompile("^" + regDateTime + regTag + message + " app=beaver user=42\n$")
+	if !r.Match(w.Bytes()) {
+		t.Errorf("Logger.With failed. Got: %v", w)
+	}
+
+	w.Reset()
+
+	l.Warnw(message, "ms", 3)
+	r = regexp.MustCompile("^" + regDateTime + regTag + message + " app=beaver ms=3\n$")
+	if !r.Match(w.Bytes()) {
+		t.Errorf("Logger.Warnw failed. Got: %v", w)
+	}
+
+	w.Reset()
+
+	l.Level(Lerror).Debugw(message, "ms", 3)
+	if w.Len() != 0 {
+		t.Errorf("Logger.Debugw failed: level not respected. Got: %v", w)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w)
+
+	fns := map[string]func(){
+		"Panic":  func() { l.Panic(message) },
+		"Panicf": func() { l.Panicf("%s", message) },
+		"Panicw": func() { l.Panicw(message) },
+	}
+
+	for name, fn := range fns {
+		func() {
+			defer func() {
+				if r := recover(); r != message {
+					t.Errorf("Logger.%s failed: panic value not match. Got: %v", name, r)
+				}
+			}()
+			fn()
+		}()
+
+		if !reg.Match(w.Bytes()) || !strings.Contains(w.String(), DefaultLogTag.Panic) {
+			t.Errorf("Logger.%s failed. Got: %v", name, w)
+		}
+		w.Reset()
+	}
+
+	// panics even if level is disabled
+	defer func() {
+		if recover() == nil {
+			t.Error("Panic failed: should panic at level 0")
+		}
+		if w.Len() != 0 {
+			t.Errorf("Panic failed: level not respected. Got: %v", w)
+		}
+	}()
+	LogOutput(w)
+	LogLevel(0)
+	defer LogLevel(Lall)
+	Panic(message)
+}
+
+func TestExitFunc(t *testing.T) {
+	w := new(bytes.Buffer)
+	code := 0
+	l := NewLogger().Output(w).ExitFunc(func(c int) { code = c })
+
+	var order []int
+	RegisterExitHandler(func() { order = append(order, 1) })
+	RegisterExitHandler(func() { panic("handler panic!") })
+	RegisterExitHandler(func() { order = append(order, 3) })
+
+	l.With("k", "v").Fatalw(message)
+	if code != 1 {
+		t.Errorf("Logger.ExitFunc failed: exit function not called. Got code: %d", code)
+	}
+	if len(order) != 2 || order[0] != 3 || order[1] != 1 {
+		t.Errorf("RegisterExitHandler failed: handlers should run in reverse order. Got: %v", order)
+	}
+	if !strings.Contains(w.String(), DefaultLogTag.Fatal+" "+message+" k=v") {
+		t.Errorf("Logger.Fatalw failed. Got: %v", w)
+	}
+
+	// handlers run only once
+	code = 0
+	l.Fatal(message)
+	if code != 1 || len(order) != 2 {
+		t.Errorf("Logger.Exit failed: code %d, handlers %v", code, order)
+	}
+}
+
+func TestReconfigureWhileLogging(t *testing.T) {
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w)
+	c := l.With("k", "v").Named("child")
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 200; i++ {
+			l.Info(message)
+			c.Warnf("%s", message)
+		}
+		close(done)
+	}()
+
+	for i := 0; i < 200; i++ {
+		l.Level(Lall &^ Ldebug)
+		l.Tags(BracketLogTag)
+		l.Prefix("app ")
+		l.Flags(log.LstdFlags | log.Lshortfile)
+		l.Formatter(TextFormatter{})
+		l.ExitFunc(nil)
+		c.Tags(DefaultLogTag)
+	}
+	<-done
+
+	if w.Len() == 0 {
+		t.Error("Logger failed: nothing written while reconfiguring")
+	}
+}
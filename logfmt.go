@@ -0,0 +1,65 @@
+package beaver
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// appendLogfmt writes key-value pairs kv to b in logfmt style, each
+// preceded by a space. A key missing its value is paired with
+// "(MISSING)".
+func appendLogfmt(b *bytes.Buffer, kv []interface{}) {
+	for i := 0; i < len(kv); i += 2 {
+		b.WriteByte(' ')
+		writeLogfmt(b, fmt.Sprint(kv[i]))
+		b.WriteByte('=')
+
+		if i+1 < len(kv) {
+			writeLogfmt(b, logfmtValue(kv[i+1]))
+		} else {
+			b.WriteString("(MISSING)")
+		}
+	}
+}
+
+// logfmtValue returns the string form of v.
+func logfmtValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// writeLogfmt writes s to b, quoting it if necessary.
+func writeLogfmt(b *bytes.Buffer, s string) {
+	if needsQuote(s) {
+		b.WriteString(strconv.Quote(s))
+	} else {
+		b.WriteString(s)
+	}
+}
+
+// needsQuote reports whether s is empty or contains space, quotes,
+// equal signs or non-printable characters.
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+
+	for _, r := range s {
+		if r == ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
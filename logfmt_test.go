@@ -0,0 +1,31 @@
+package beaver
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAppendLogfmt(t *testing.T) {
+	cases := []struct {
+		kv   []interface{}
+		want string
+	}{
+		{nil, ""},
+		{[]interface{}{"user", 42}, " user=42"},
+		{[]interface{}{"ms", 3 * time.Millisecond, "ok", true}, " ms=3ms ok=true"},
+		{[]interface{}{"msg", "hello world", "empty", ""}, ` msg="hello world" empty=""`},
+		{[]interface{}{"err", errors.New(`say "hi"`)}, ` err="say \"hi\""`},
+		{[]interface{}{"a=b", "x\ny", "nil", nil}, ` "a=b"="x\ny" nil=nil`},
+		{[]interface{}{"odd"}, " odd=(MISSING)"},
+	}
+
+	for _, c := range cases {
+		var b bytes.Buffer
+		appendLogfmt(&b, c.kv)
+		if b.String() != c.want {
+			t.Errorf("appendLogfmt(%v) failed\nGot:  %s\nWant: %s", c.kv, b.String(), c.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package beaver
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Named returns a child Logger with given name, which is appended to
+// the name of l with a dot. For example, l.Named("db").Named("sql") is
+// named "db.sql". The name is written in each log, and selects the
+// rules set by SetLevels. Like With, the child shares the output with l.
+func (l *Logger) Named(name string) *Logger {
+	c := l.With()
+	if l.name != "" && name != "" {
+		c.name = l.name + "." + name
+	} else {
+		c.name = l.name + name
+	}
+	return c
+}
+
+// lvInherit is the level of a child Logger which follows its parent.
+const lvInherit = -1
+
+// level returns the effective level of l, which is the one of the most
+// specific rule set by SetLevels matching its name, or its own level if
+// no rules match.
+func (l *Logger) level() int {
+	if lv, ok := levelRules.lookup(l.name); ok {
+		return lv
+	}
+	return l.ownLevel()
+}
+
+// ownLevel returns the level set to l, or the one of its nearest ancestor
+// if not set.
+func (l *Logger) ownLevel() int {
+	for {
+		lv := int(atomic.LoadInt32(&l.lv))
+		if lv != lvInherit || l.up == nil {
+			return lv
+		}
+		l = l.up
+	}
+}
+
+// A levelRule sets the level of loggers by name.
+type levelRule struct {
+	name string
+	lv   int
+}
+
+// A ruleSet is an immutable set of rules, sorted by descending length of
+// name, with a cache of looked up names.
+type ruleSet struct {
+	rules []levelRule
+	cache sync.Map
+}
+
+// match reports whether rule r applies to the logger named name.
+func (r levelRule) match(name string) bool {
+	return r.name == "*" || name == r.name ||
+		strings.HasPrefix(name, r.name) && name[len(r.name)] == '.'
+}
+
+// rules holds the current *ruleSet
+type rules struct {
+	v atomic.Value
+}
+
+var levelRules rules
+
+// lookup returns the level of the most specific rule matching name.
+func (rs *rules) lookup(name string) (int, bool) {
+	set, _ := rs.v.Load().(*ruleSet)
+	if set == nil {
+		return 0, false
+	}
+
+	if v, ok := set.cache.Load(name); ok {
+		lv := v.(int)
+		return lv, lv >= 0
+	}
+
+	lv := -1
+	for _, r := range set.rules {
+		if r.match(name) {
+			lv = r.lv
+			break
+		}
+	}
+	set.cache.Store(name, lv)
+	return lv, lv >= 0
+}
+
+// SetLevels replaces the rules which set the levels of loggers by name.
+// The rules is a comma-separated list of "name=level", where the level
+// is parsed by ParseLevel. For example:
+//
+//	db=debug,http=warn,*=info
+//
+// A rule applies to the logger of the name and its descendants, e.g.
+// "db" applies to "db" and "db.sql" but not "dbx". The rule of name "*"
+// applies to all loggers, including unnamed ones. If more than one rule
+// applies, the one with the longest name wins. The rules take precedence
+// over the level set by Logger.Level. To use a list in level, separate
+// the items by commas as well, e.g. "db=error,debug,*=info". An empty
+// string removes all rules. It is safe to call SetLevels while logging.
+func SetLevels(rules string) error {
+	if strings.TrimSpace(rules) == "" {
+		levelRules.v.Store((*ruleSet)(nil))
+		return nil
+	}
+
+	// items without name belong to the level of previous rule
+	var names, lvs []string
+	for _, item := range strings.Split(rules, ",") {
+		i := strings.IndexByte(item, '=')
+		if i < 0 || strings.TrimSpace(item[:i]) == "" {
+			if len(lvs) == 0 {
+				return errors.New("beaver: invalid level rule " + item)
+			}
+			lvs[len(lvs)-1] += "," + item
+			continue
+		}
+
+		names = append(names, strings.TrimSpace(item[:i]))
+		lvs = append(lvs, item[i+1:])
+	}
+
+	return setLevelRules(names, lvs)
+}
+
+// setLevelRules replaces the rules by names and their levels in the
+// syntax of ParseLevel. The rules are unchanged if any of them is
+// invalid.
+func setLevelRules(names, lvs []string) error {
+	set := &ruleSet{}
+	seen := make(map[string]bool)
+	for i, name := range names {
+		if seen[name] {
+			return errors.New("beaver: duplicated level rule of " + name)
+		}
+		seen[name] = true
+
+		if name == "" {
+			return errors.New("beaver: empty name in level rules")
+		}
+
+		lv, err := ParseLevel(lvs[i])
+		if err != nil {
+			return err
+		}
+		set.rules = append(set.rules, levelRule{name, lv})
+	}
+
+	// "*" is the least specific
+	sort.Slice(set.rules, func(i, j int) bool {
+		ri, rj := set.rules[i], set.rules[j]
+		if ri.name == "*" || rj.name == "*" {
+			return rj.name == "*" && ri.name != "*"
+		}
+		return len(ri.name) > len(rj.name)
+	})
+
+	levelRules.v.Store(set)
+	return nil
+}
+
+// Levels returns the rules set by SetLevels, sorted by name, which can
+// be passed to SetLevels.
+func Levels() string {
+	var r []string
+	for name, lv := range levelRules.levels() {
+		r = append(r, name+"="+lv)
+	}
+	sort.Strings(r)
+	return strings.Join(r, ",")
+}
+
+// levels returns the names of rules and their levels formatted by
+// FormatLevel.
+func (rs *rules) levels() map[string]string {
+	m := make(map[string]string)
+	if set, _ := rs.v.Load().(*ruleSet); set != nil {
+		for _, r := range set.rules {
+			m[r.name] = FormatLevel(r.lv)
+		}
+	}
+	return m
+}
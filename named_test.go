@@ -0,0 +1,94 @@
+package beaver
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w).Named("db").Named("sql")
+
+	if l.name != "db.sql" {
+		t.Errorf("Logger.Named failed. Got: %s, Want: db.sql", l.name)
+	}
+
+	l.Info(message)
+	r := regexp.MustCompile("^" + regDateTime + regTag + "db.sql: " + message + "\n$")
+	if !r.Match(w.Bytes()) {
+		t.Errorf("Logger.Named failed. Got: %v", w)
+	}
+}
+
+func TestChildLevel(t *testing.T) {
+	l := NewLogger().Level(Linfo)
+	db := l.With("k", 1).Named("db")
+	own := l.Named("own").Level(Lerror)
+
+	// children follow the level of parent, unless their own is set
+	l.Level(Ldebug)
+	if lv := db.level(); lv != Ldebug {
+		t.Errorf("Logger.Named failed: level of parent not followed. Got: %d, Want: %d", lv, Ldebug)
+	}
+	if lv := own.level(); lv != Lerror {
+		t.Errorf("Logger.Level failed: level of child not kept. Got: %d, Want: %d", lv, Lerror)
+	}
+	if lv := own.Named("sub").level(); lv != Lerror {
+		t.Errorf("Logger.Named failed: level of nearest ancestor not followed. Got: %d, Want: %d", lv, Lerror)
+	}
+}
+
+func TestSetLevels(t *testing.T) {
+	defer SetLevels("")
+
+	if err := SetLevels("db=debug, http=error,=warn, *=info"); err != nil {
+		t.Fatal("SetLevels failed:", err)
+	}
+
+	info, _ := ParseLevel("info")
+	debug, _ := ParseLevel("debug")
+	l := NewLogger().Level(Lfatal)
+	cases := map[*Logger]int{
+		l:                             info,
+		l.Named("db"):                 debug,
+		l.Named("db").Named("sql"):    debug,
+		l.Named("dbx"):                info,
+		l.Named("http"):               Lerror | Lwarn,
+		l.Named("http").With("k", 1):  Lerror | Lwarn,
+		NewLogger().Named("http.api"): Lerror | Lwarn,
+	}
+	for lg, want := range cases {
+		if lv := lg.level(); lv != want {
+			t.Errorf("SetLevels failed: level of %q not match. Got: %d, Want: %d", lg.name, lv, want)
+		}
+	}
+
+	if s := Levels(); s != "*=info+,db=debug+,http=error,warn" {
+		t.Errorf("Levels failed. Got: %s", s)
+	}
+
+	// rules can be changed at runtime
+	SetLevels("db=none")
+	if lv := l.Named("db").level(); lv != 0 {
+		t.Errorf("SetLevels failed: rules not replaced. Got: %d", lv)
+	}
+	if lv := l.level(); lv != Lfatal {
+		t.Errorf("SetLevels failed: level of Logger should be used if no rules match. Got: %d", lv)
+	}
+
+	w := new(bytes.Buffer)
+	l.Output(w).Named("db").Error(message)
+	if w.Len() != 0 {
+		t.Errorf("SetLevels failed: rule not respected. Got: %v", w)
+	}
+
+	for _, s := range []string{"db", "=info", "db=loud", "db=info,db=warn"} {
+		if err := SetLevels(s); err == nil {
+			t.Errorf("SetLevels(%q) failed: invalid rules should cause an error", s)
+		}
+	}
+	if s := Levels(); s != "db=none" {
+		t.Errorf("SetLevels failed: rules should be unchanged on error. Got: %s", s)
+	}
+}
@@ -0,0 +1,354 @@
+package beaver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// the timeout of dialing a collector
+const netDialTimeout = 10 * time.Second
+
+// A NetWriter is an io.WriteCloser which streams logs to a collector over
+// the network, e.g. TCP, optionally with TLS. Each write is sent as a
+// frame, which is either terminated by newline, the default, or prefixed
+// by its length. The frames are queued and sent by another goroutine,
+// which reconnects with exponential backoff when the connection fails.
+// While disconnected, the frames are buffered up to a limit, and the ones
+// beyond it are dropped. A frame interrupted by a failure is sent again
+// after reconnected. It is safe for concurrent use, so can be used as
+// output of Logger or httplog.Logger.
+type NetWriter struct {
+	network, addr string
+
+	mu       sync.Mutex
+	cond     *sync.Cond // signaled when a frame is queued or n is closed
+	tls      *tls.Config
+	prefix   bool
+	limit    int
+	min, max time.Duration
+	timeout  time.Duration
+
+	queue   [][]byte
+	size    int // total bytes in queue
+	dropped uint64
+	closed  bool
+	conn    net.Conn // the current connection, if any
+	err     error    // the last error of connection
+
+	ctx    context.Context // canceled when Close times out
+	cancel context.CancelFunc
+	stop   chan struct{} // closed on Close to interrupt backoff
+	done   chan struct{}
+}
+
+// NewNetWriter returns a NetWriter which sends logs to addr on the named
+// network, as net.Dial does. The connection is established in background
+// on first write. By default, up to 1 MiB of frames are buffered, and the
+// backoff of reconnecting grows from 100 milliseconds to 30 seconds, and
+// the timeout is 10 seconds.
+func NewNetWriter(network, addr string) *NetWriter {
+	n := &NetWriter{
+		network: network,
+		addr:    addr,
+		limit:   1 << 20,
+		min:     100 * time.Millisecond,
+		max:     30 * time.Second,
+		timeout: 10 * time.Second,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	n.cond = sync.NewCond(&n.mu)
+	go n.run()
+	return n
+}
+
+// TLS sets the configuration of TLS connection. A nil c disables TLS.
+func (n *NetWriter) TLS(c *tls.Config) *NetWriter {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.tls = c
+	return n
+}
+
+// LengthPrefix sets whether frames are prefixed by their length in 4-byte
+// big-endian, instead of terminated by newline. The trailing newline of
+// each write is removed from length-prefixed frames.
+func (n *NetWriter) LengthPrefix(b bool) *NetWriter {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.prefix = b
+	return n
+}
+
+// Buffer sets the maximum bytes of frames buffered while disconnected.
+func (n *NetWriter) Buffer(size int) *NetWriter {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.limit = size
+	return n
+}
+
+// Backoff sets the minimum and maximum delay between attempts of
+// reconnecting. The delay doubles after each failure.
+func (n *NetWriter) Backoff(min, max time.Duration) *NetWriter {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.min, n.max = min, max
+	return n
+}
+
+// Timeout sets the deadline of sending each frame, after which the
+// connection is considered failed, and the time Close waits for the
+// queued frames to be sent. A non-positive d means no deadline, and Close
+// waits until the frames are sent or the connection fails.
+func (n *NetWriter) Timeout(d time.Duration) *NetWriter {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.timeout = d
+	return n
+}
+
+// Write implements the io.Writer interface. The p is framed and queued
+// to send later. A frame dropped due to a full buffer is counted by
+// Dropped, and reports no error. It returns os.ErrClosed if n is closed.
+func (n *NetWriter) Write(p []byte) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return 0, os.ErrClosed
+	}
+
+	f := n.frame(p)
+	if n.size+len(f) > n.limit {
+		n.dropped++
+		return len(p), nil
+	}
+
+	n.queue = append(n.queue, f)
+	n.size += len(f)
+	n.cond.Broadcast()
+	return len(p), nil
+}
+
+// Dropped returns the number of frames dropped due to a full buffer.
+func (n *NetWriter) Dropped() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.dropped
+}
+
+// Close sends the queued frames and closes the connection. If the
+// connection fails, or the frames are not sent within the timeout, the
+// rest frames are dropped instead of retried, and an error reporting the
+// number of them and the last error of connection is returned.
+func (n *NetWriter) Close() error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return os.ErrClosed
+	}
+	n.closed = true
+	dropped, timeout := n.dropped, n.timeout
+	close(n.stop)
+	n.cond.Broadcast()
+	n.mu.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+
+	select {
+	case <-n.done:
+	case <-expired:
+		// interrupt dialing or writing
+		n.cancel()
+		n.mu.Lock()
+		if n.conn != nil {
+			n.conn.Close()
+		}
+		n.mu.Unlock()
+		<-n.done
+	}
+	n.cancel()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.dropped == dropped {
+		return nil
+	}
+
+	msg := "beaver: " + strconv.FormatUint(n.dropped-dropped, 10) + " frames dropped on close"
+	if n.err != nil {
+		msg += ": " + n.err.Error()
+	}
+	return errors.New(msg)
+}
+
+// frame returns a copy of p framed by the configuration of n. The caller
+// must hold n.mu.
+func (n *NetWriter) frame(p []byte) []byte {
+	if !n.prefix {
+		f := append([]byte(nil), p...)
+		if len(f) == 0 || f[len(f)-1] != '\n' {
+			f = append(f, '\n')
+		}
+		return f
+	}
+
+	p = bytes.TrimSuffix(p, []byte("\n"))
+	f := make([]byte, 4, 4+len(p))
+	binary.BigEndian.PutUint32(f, uint32(len(p)))
+	return append(f, p...)
+}
+
+// dial connects to the collector by the configuration of n.
+func (n *NetWriter) dial() (net.Conn, error) {
+	n.mu.Lock()
+	c := n.tls
+	n.mu.Unlock()
+
+	d := &net.Dialer{Timeout: netDialTimeout}
+	if c != nil {
+		return (&tls.Dialer{NetDialer: d, Config: c}).DialContext(n.ctx, n.network, n.addr)
+	}
+	return d.DialContext(n.ctx, n.network, n.addr)
+}
+
+// send writes f to conn within the timeout.
+func (n *NetWriter) send(conn net.Conn, f []byte) error {
+	n.mu.Lock()
+	d := n.timeout
+	n.mu.Unlock()
+
+	var deadline time.Time
+	if d > 0 {
+		deadline = time.Now().Add(d)
+	}
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	_, err := conn.Write(f)
+	return err
+}
+
+// setConn sets the current connection and the last error of n.
+func (n *NetWriter) setConn(conn net.Conn, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.conn = conn
+	if err != nil {
+		n.err = err
+	}
+}
+
+// run sends the queued frames until n is closed and the queue is empty
+// or the collector can't be connected.
+func (n *NetWriter) run() {
+	defer close(n.done)
+
+	var conn net.Conn
+	var delay time.Duration
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		n.mu.Lock()
+		for len(n.queue) == 0 && !n.closed {
+			n.cond.Wait()
+		}
+		if len(n.queue) == 0 {
+			n.mu.Unlock()
+			return
+		}
+		f, closed := n.queue[0], n.closed
+		n.mu.Unlock()
+
+		if conn == nil {
+			var err error
+			conn, err = n.dial()
+			n.setConn(conn, err)
+			if err != nil {
+				if closed {
+					n.dropAll()
+					return
+				}
+
+				delay = n.sleep(delay)
+				continue
+			}
+		}
+
+		if err := n.send(conn, f); err != nil {
+			conn.Close()
+			conn = nil
+			n.setConn(nil, err)
+			if closed {
+				n.dropAll()
+				return
+			}
+			delay = n.sleep(delay)
+			continue
+		}
+
+		delay = 0
+		n.mu.Lock()
+		n.queue[0] = nil
+		n.queue = n.queue[1:]
+		n.size -= len(f)
+		n.mu.Unlock()
+	}
+}
+
+// sleep waits for the backoff following d until n is closed, and returns
+// the backoff.
+func (n *NetWriter) sleep(d time.Duration) time.Duration {
+	d = n.backoff(d)
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-n.stop:
+	}
+	return d
+}
+
+// dropAll drops the queued frames.
+func (n *NetWriter) dropAll() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.dropped += uint64(len(n.queue))
+	n.queue, n.size = nil, 0
+}
+
+// backoff returns the delay following d.
+func (n *NetWriter) backoff(d time.Duration) time.Duration {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if d *= 2; d < n.min {
+		d = n.min
+	}
+	if d > n.max {
+		d = n.max
+	}
+	return d
+}
@@ -0,0 +1,200 @@
+package beaver
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// readLines accepts a connection from ln and returns the first n lines.
+func readLines(t *testing.T, ln net.Listener, n int) []string {
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal("Listener.Accept exits with error:", err)
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var lines []string
+	r := bufio.NewReader(c)
+	for len(lines) < n {
+		s, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal("NetWriter failed: reading line:", err)
+		}
+		lines = append(lines, s)
+	}
+	return lines
+}
+
+func TestNetWriter(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("net.Listen exits with error:", err)
+	}
+	defer ln.Close()
+
+	w := NewNetWriter("tcp", ln.Addr().String())
+	defer w.Close()
+
+	l := NewLogger().Output(w)
+	l.Info(message)
+	w.Write([]byte("no newline"))
+
+	lines := readLines(t, ln, 2)
+	if !reg.MatchString(lines[0]) || lines[1] != "no newline\n" {
+		t.Errorf("NetWriter failed: frames not match. Got: %q", lines)
+	}
+}
+
+func TestNetWriterReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("net.Listen exits with error:", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	// logs are buffered while the collector is down, up to 13 bytes
+	w := NewNetWriter("tcp", addr).Backoff(time.Millisecond, 10*time.Millisecond).Buffer(13)
+	defer w.Close()
+
+	for _, s := range []string{"first", "second", "dropped"} {
+		w.Write([]byte(s))
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if ln, err = net.Listen("tcp", addr); err != nil {
+		t.Skip("address not reusable:", err)
+	}
+	defer ln.Close()
+
+	lines := readLines(t, ln, 2)
+	if lines[0] != "first\n" || lines[1] != "second\n" {
+		t.Errorf("NetWriter failed: buffered frames not sent. Got: %q", lines)
+	}
+	if n := w.Dropped(); n != 1 {
+		t.Errorf("NetWriter.Dropped failed. Got: %d, Want: 1", n)
+	}
+}
+
+func TestNetWriterTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	client := srv.Client().Transport.(*http.Transport).TLSClientConfig
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", srv.TLS)
+	srv.Close()
+	if err != nil {
+		t.Fatal("tls.Listen exits with error:", err)
+	}
+	defer ln.Close()
+
+	w := NewNetWriter("tcp", ln.Addr().String()).TLS(client).LengthPrefix(true)
+	w.Write([]byte("Hello\n"))
+
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal("Listener.Accept exits with error:", err)
+	}
+	defer c.Close()
+
+	var size uint32
+	if err = binary.Read(c, binary.BigEndian, &size); err != nil {
+		t.Fatal("NetWriter failed: reading length:", err)
+	}
+	b := make([]byte, size)
+	if _, err = io.ReadFull(c, b); err != nil || string(b) != "Hello" {
+		t.Errorf("NetWriter failed: length-prefixed frame not match. Got: %q, %v", b, err)
+	}
+
+	if err = w.Close(); err != nil {
+		t.Error("NetWriter.Close failed:", err)
+	}
+}
+
+func TestNetWriterTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("net.Listen exits with error:", err)
+	}
+	defer ln.Close()
+
+	// the collector accepts the connection, but never reads
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			defer c.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	w := NewNetWriter("tcp", ln.Addr().String()).Buffer(64 << 20).Timeout(100 * time.Millisecond)
+	b := make([]byte, 1<<20)
+	for i := 0; i < 64; i++ {
+		w.Write(b)
+	}
+
+	start := time.Now()
+	err = w.Close()
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("NetWriter.Close failed: returned after %v", d)
+	}
+	if err == nil || w.Dropped() == 0 {
+		t.Errorf("NetWriter.Close failed: dropped frames not reported. Got: %v, %d dropped", err, w.Dropped())
+	}
+}
+
+func TestNetWriterBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("net.Listen exits with error:", err)
+	}
+	defer ln.Close()
+
+	var conns int32
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&conns, 1)
+			defer c.Close()
+		}
+	}()
+
+	// every write fails as the deadline is exceeded immediately
+	w := NewNetWriter("tcp", ln.Addr().String()).Timeout(time.Nanosecond).Backoff(50*time.Millisecond, 50*time.Millisecond)
+	for i := 0; i < 100; i++ {
+		w.Write([]byte("Hello"))
+	}
+	time.Sleep(200 * time.Millisecond)
+	w.Close()
+
+	if n := atomic.LoadInt32(&conns); n > 10 {
+		t.Errorf("NetWriter failed: %d connections without backoff", n)
+	}
+}
+
+func TestNetWriterNoTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("net.Listen exits with error:", err)
+	}
+	defer ln.Close()
+
+	// non-positive timeout means no deadline
+	w := NewNetWriter("tcp", ln.Addr().String()).Timeout(0)
+	defer w.Close()
+	w.Write([]byte("Hello"))
+
+	if lines := readLines(t, ln, 1); lines[0] != "Hello\n" {
+		t.Errorf("NetWriter failed: frame not sent without timeout. Got: %q", lines)
+	}
+}
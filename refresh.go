@@ -0,0 +1,211 @@
+package beaver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Refresher fetches JSON-encoded data from an URL periodically. Each
+// successful fetch is decoded into a new value which replaces the
+// previous one atomically, so readers never observe a partially
+// decoded value. Conditional requests (If-None-Match and
+// If-Modified-Since) are issued once the server provides an ETag or
+// Last-Modified header.
+type Refresher struct {
+	url  string
+	h    http.Header
+	typ  reflect.Type
+	d    time.Duration
+	to   time.Duration // timeout of each fetch; the interval if zero
+	jit  float64
+	v    atomic.Value
+	mu   sync.Mutex
+	etag string
+	mod  string
+	last time.Time
+	err  error
+}
+
+// Refresher returns a Refresher which fetches JSON-encoded data from
+// given url with header h. If h is nil, a default http.Header is applied.
+// The value of j is used until the first successful fetch. By default,
+// the interval is one minute with 10% jitter.
+func (j *JSONPod) Refresher(url string, h http.Header) *Refresher {
+	r := &Refresher{url: url, h: h, d: time.Minute, jit: 0.1}
+	if t := reflect.TypeOf(j.v); t != nil && t.Kind() == reflect.Ptr {
+		r.typ = t.Elem()
+	}
+	r.v.Store(box{j.v})
+	return r
+}
+
+// box wraps the value stored in a Refresher, since an atomic.Value
+// requires values of consistent concrete type.
+type box struct {
+	v interface{}
+}
+
+// Interval sets the duration between two fetches. It panics if d is
+// not positive.
+func (r *Refresher) Interval(d time.Duration) *Refresher {
+	if d <= 0 {
+		panic("beaver: non-positive interval for Refresher")
+	}
+	r.d = d
+	return r
+}
+
+// Timeout sets the time limit of each fetch, including reading the
+// response body. It's the interval by default. It panics if d is not
+// positive.
+func (r *Refresher) Timeout(d time.Duration) *Refresher {
+	if d <= 0 {
+		panic("beaver: non-positive timeout for Refresher")
+	}
+	r.to = d
+	return r
+}
+
+// Jitter sets the ratio of the interval which is randomly added to or
+// subtracted from each wait. For example, 0.1 with a 1-minute interval
+// results in waits between 54 and 66 seconds. It panics if f is not in
+// range [0, 1).
+func (r *Refresher) Jitter(f float64) *Refresher {
+	if f < 0 || f >= 1 {
+		panic("beaver: jitter of Refresher out of range [0, 1)")
+	}
+	r.jit = f
+	return r
+}
+
+// Value returns the pointer to the latest successfully fetched value.
+// The returned value must not be modified.
+func (r *Refresher) Value() interface{} {
+	return r.v.Load().(box).v
+}
+
+// LastSuccess returns the time of the latest successful fetch, including
+// the ones the server responded with status Not Modified. A zero Time
+// is returned if no fetch has succeeded.
+func (r *Refresher) LastSuccess() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Err returns the error of the latest fetch, or nil if it succeeded.
+func (r *Refresher) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Run fetches the data immediately and then on every interval until ctx
+// is done. It blocks until then and returns ctx.Err(). Errors from each
+// fetch are reported by r.Err instead.
+func (r *Refresher) Run(ctx context.Context) error {
+	if r.typ == nil {
+		return errors.New("beaver: Refresher requires a JSONPod with non-nil pointer")
+	}
+
+	for {
+		r.Fetch(ctx)
+
+		t := time.NewTimer(r.wait())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// wait returns the interval with random jitter applied.
+func (r *Refresher) wait() time.Duration {
+	if r.jit == 0 {
+		return r.d
+	}
+	return r.d + time.Duration((rand.Float64()*2-1)*r.jit*float64(r.d))
+}
+
+// Fetch issues a request to the URL and replaces the value of r if the
+// server responses with new data. The fetch is canceled if it doesn't
+// complete within the timeout. It is called by r.Run on every interval,
+// but can be used to force an update.
+func (r *Refresher) Fetch(ctx context.Context) error {
+	err := r.fetch(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+	if err == nil {
+		r.last = time.Now()
+	}
+	return err
+}
+
+func (r *Refresher) fetch(ctx context.Context) error {
+	if r.typ == nil {
+		return errors.New("beaver: Refresher requires a JSONPod with non-nil pointer")
+	}
+
+	to := r.to
+	if to == 0 {
+		to = r.d
+	}
+	ctx, cancel := context.WithTimeout(ctx, to)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", r.url, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range r.h {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	req.Header.Add("Accept", "application/json")
+
+	r.mu.Lock()
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	if r.mod != "" {
+		req.Header.Set("If-Modified-Since", r.mod)
+	}
+	r.mu.Unlock()
+
+	res, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if res.StatusCode >= 300 {
+		return errors.New("beaver: Server response with status " + res.Status)
+	}
+
+	v := reflect.New(r.typ).Interface()
+	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
+		return err
+	}
+	r.v.Store(box{v})
+
+	r.mu.Lock()
+	r.etag = res.Header.Get("ETag")
+	r.mod = res.Header.Get("Last-Modified")
+	r.mu.Unlock()
+	return nil
+}
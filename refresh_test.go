@@ -0,0 +1,136 @@
+package beaver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefresherFetch(t *testing.T) {
+	var hits, notModified int32
+	s := sample{Name: "Beaver", Year: 2017, Fast: true}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		JSON(&s).Serve(w, http.StatusOK)
+	}))
+	defer ts.Close()
+
+	init := sample{Name: "Init"}
+	r := JSON(&init).Refresher(ts.URL, nil)
+	if r.Value().(*sample) != &init {
+		t.Error("Refresher.Value failed: initial value should be the one of JSONPod")
+	}
+	if !r.LastSuccess().IsZero() {
+		t.Error("Refresher.LastSuccess failed: should be zero before fetching")
+	}
+
+	if err := r.Fetch(context.Background()); err != nil {
+		t.Fatal("Refresher.Fetch failed:", err)
+	}
+
+	out := r.Value().(*sample)
+	if *out != s {
+		t.Errorf("Refresher.Fetch failed. Got: %v, Want: %v", *out, s)
+	}
+	if init.Name != "Init" {
+		t.Error("Refresher.Fetch failed: original value should not be modified")
+	}
+
+	if err := r.Fetch(context.Background()); err != nil {
+		t.Fatal("Refresher.Fetch failed:", err)
+	}
+	if n := atomic.LoadInt32(&notModified); n != 1 {
+		t.Errorf("Refresher.Fetch failed: conditional request not issued. Got %d Not Modified, Want: 1", n)
+	}
+	if r.Value().(*sample) != out {
+		t.Error("Refresher.Fetch failed: value should be kept when server responses Not Modified")
+	}
+	if r.LastSuccess().IsZero() || r.Err() != nil {
+		t.Errorf("Refresher.Fetch failed: last success %v, error %v", r.LastSuccess(), r.Err())
+	}
+}
+
+func TestRefresherError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	out := sample{}
+	r := JSON(&out).Refresher(ts.URL, nil)
+	if err := r.Fetch(context.Background()); err == nil || r.Err() != err {
+		t.Errorf("Refresher.Fetch failed: error not reported. Got: %v, %v", err, r.Err())
+	}
+	if r.Value().(*sample) != &out {
+		t.Error("Refresher.Fetch failed: value should be kept on error")
+	}
+
+	if err := JSON(out).Refresher(ts.URL, nil).Fetch(context.Background()); err == nil {
+		t.Error("Refresher.Fetch failed: non-pointer value should cause an error")
+	}
+}
+
+func TestRefresherTimeout(t *testing.T) {
+	stop := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stop // never responses
+	}))
+	defer ts.Close()
+	defer close(stop)
+
+	r := JSON(&sample{}).Refresher(ts.URL, nil).Timeout(50 * time.Millisecond)
+	done := make(chan error, 1)
+	go func() { done <- r.Fetch(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if err == nil || r.Err() != err {
+			t.Errorf("Refresher.Fetch failed: timeout not reported. Got: %v, %v", err, r.Err())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Refresher.Fetch failed: not canceled after timeout")
+	}
+}
+
+func TestRefresherRun(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		JSON(&sample{Year: int(n)}).Serve(w, http.StatusOK)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := JSON(&sample{}).Refresher(ts.URL, nil).Interval(10 * time.Millisecond).Jitter(0.5)
+
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Refresher.Run failed: should return context error. Got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Refresher.Run failed: not stopped after context canceled")
+	}
+
+	if n := atomic.LoadInt32(&hits); n < 2 {
+		t.Errorf("Refresher.Run failed: fetched %d times, want at least 2", n)
+	}
+	if r.Value().(*sample).Year == 0 {
+		t.Error("Refresher.Run failed: value not updated")
+	}
+}
@@ -0,0 +1,143 @@
+package beaver
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// A ReopenWriter is an io.WriteCloser which writes to a file and is able
+// to close and reopen it by the same path, e.g. after the file is moved
+// by logrotate. The file is reopened when Reopen is called or the process
+// receives SIGHUP. It is safe for concurrent use, so can be used as
+// output of Logger or httplog.Logger.
+type ReopenWriter struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// OpenReopenWriter opens the file in given path and returns a
+// ReopenWriter. The file is appended if it exists, or created with
+// permission mode 0644. The writer reopens the file on SIGHUP until it
+// is closed.
+func OpenReopenWriter(path string) (*ReopenWriter, error) {
+	f, err := openAppend(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &ReopenWriter{path: path, f: f}
+	sighup.add(w)
+	return w, nil
+}
+
+// Write implements the io.Writer interface. It returns os.ErrClosed if
+// w is closed.
+func (w *ReopenWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+	return w.f.Write(p)
+}
+
+// Reopen closes the file and opens it again by the path. If the file
+// can't be opened, the original one is kept for writing.
+// It returns os.ErrClosed without opening the file if w is closed.
+func (w *ReopenWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return os.ErrClosed
+	}
+
+	f, err := openAppend(w.path)
+	if err != nil {
+		return err
+	}
+
+	old := w.f
+	w.f = f
+	return old.Close()
+}
+
+// Close closes the file and stops reopening it on SIGHUP.
+func (w *ReopenWriter) Close() error {
+	sighup.remove(w)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return os.ErrClosed
+	}
+
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+func openAppend(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+// sighup is the set of ReopenWriters which are reopened on SIGHUP. The
+// signal is only caught while the set is not empty, and never on platforms
+// without SIGHUP.
+var sighup reopenSet
+
+type reopenSet struct {
+	mu sync.Mutex
+	ws map[*ReopenWriter]bool
+	ch chan os.Signal
+}
+
+func (s *reopenSet) add(w *ReopenWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ws == nil {
+		s.ws = make(map[*ReopenWriter]bool)
+	}
+	s.ws[w] = true
+
+	if s.ch == nil && len(reopenSignals) > 0 {
+		s.ch = make(chan os.Signal, 1)
+		signal.Notify(s.ch, reopenSignals...)
+		go s.watch(s.ch)
+	}
+}
+
+func (s *reopenSet) remove(w *ReopenWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.ws, w)
+	if len(s.ws) == 0 && s.ch != nil {
+		signal.Stop(s.ch)
+		close(s.ch)
+		s.ch = nil
+	}
+}
+
+// watch reopens all writers in s whenever a signal is received from ch.
+// The writers are reopened without holding s.mu, so Close is never
+// blocked by a slow Reopen.
+func (s *reopenSet) watch(ch chan os.Signal) {
+	for range ch {
+		s.mu.Lock()
+		ws := make([]*ReopenWriter, 0, len(s.ws))
+		for w := range s.ws {
+			ws = append(ws, w)
+		}
+		s.mu.Unlock()
+
+		for _, w := range ws {
+			w.Reopen()
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build js || plan9
+// +build js plan9
+
+package beaver
+
+import "os"
+
+// SIGHUP is not available; files are only reopened by Reopen.
+var reopenSignals []os.Signal
@@ -0,0 +1,11 @@
+//go:build !js && !plan9
+// +build !js,!plan9
+
+package beaver
+
+import (
+	"os"
+	"syscall"
+)
+
+var reopenSignals = []os.Signal{syscall.SIGHUP}
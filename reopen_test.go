@@ -0,0 +1,55 @@
+package beaver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReopenWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beaver")
+	if err != nil {
+		t.Fatal("ioutil.TempDir exits with error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	w, err := OpenReopenWriter(path)
+	if err != nil {
+		t.Fatal("OpenReopenWriter exits with error:", err)
+	}
+
+	// the file is moved like logrotate does, then reopened
+	w.Write([]byte("before"))
+	if err = os.Rename(path, path+".1"); err != nil {
+		t.Fatal("os.Rename exits with error:", err)
+	}
+	w.Write([]byte(" rename"))
+	if err = w.Reopen(); err != nil {
+		t.Fatal("ReopenWriter.Reopen failed:", err)
+	}
+	w.Write([]byte("after"))
+
+	if err = w.Close(); err != nil {
+		t.Error("ReopenWriter.Close failed:", err)
+	}
+	if _, err = w.Write([]byte("closed")); err != os.ErrClosed {
+		t.Errorf("ReopenWriter.Write failed: got error %v after closed, want %v", err, os.ErrClosed)
+	}
+
+	for name, want := range map[string]string{path + ".1": "before rename", path: "after"} {
+		b, _ := ioutil.ReadFile(name)
+		if string(b) != want {
+			t.Errorf("ReopenWriter failed: file %s not match. Got: %s, Want: %s", name, b, want)
+		}
+	}
+	// the file is not recreated after closed
+	os.Rename(path, path+".2")
+	if err = w.Reopen(); err != os.ErrClosed {
+		t.Errorf("ReopenWriter.Reopen failed: got error %v after closed, want %v", err, os.ErrClosed)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("ReopenWriter.Reopen failed: file recreated after closed: %v", err)
+	}
+}
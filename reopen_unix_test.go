@@ -0,0 +1,40 @@
+//go:build !windows && !plan9 && !js
+// +build !windows,!plan9,!js
+
+package beaver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopenWriterSIGHUP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beaver")
+	if err != nil {
+		t.Fatal("ioutil.TempDir exits with error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	w, err := OpenReopenWriter(path)
+	if err != nil {
+		t.Fatal("OpenReopenWriter exits with error:", err)
+	}
+	defer w.Close()
+
+	os.Rename(path, path+".1")
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+
+	// the file is reopened by another goroutine
+	for i := 0; i < 100; i++ {
+		if _, err = os.Stat(path); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("ReopenWriter failed: file not reopened on SIGHUP")
+}
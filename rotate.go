@@ -0,0 +1,354 @@
+package beaver
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTime is the layout of timestamps in names of rotated files.
+const backupTime = "2006-01-02T15-04-05.000"
+
+// A RotateWriter is an io.WriteCloser which writes to a file and rotates
+// it by size and/or time. The rotated files are renamed with timestamps
+// in UTC, e.g. "app.log" becomes "app-2018-02-06T00-31-28.000.log",
+// followed by a sequence number like "-1" if the name is taken, and
+// optionally compressed by gzip. It is safe for concurrent use, so can
+// be used as output of Logger or httplog.Logger.
+type RotateWriter struct {
+	path  string
+	size  int64
+	every time.Duration
+	keep  int
+	gz    bool
+
+	mu     sync.Mutex
+	f      *os.File
+	n      int64
+	opened time.Time
+	now    func() time.Time
+
+	// rotated files are compressed and cleaned up one by one in a
+	// background goroutine, which runs while jobs are pending
+	jmu     sync.Mutex
+	jcond   *sync.Cond // signaled when the goroutine exits
+	jobs    []rotateJob
+	working bool
+}
+
+// A rotateJob is the work after a file is rotated.
+type rotateJob struct {
+	name string
+	gz   bool
+	keep int
+}
+
+// NewRotateWriter returns a RotateWriter writing to the file in given
+// path. The file is opened on first write, appended if it exists, or
+// created with permission mode 0644. By default, the file is never
+// rotated unless Rotate is called, and all rotated files are kept.
+func NewRotateWriter(path string) *RotateWriter {
+	w := &RotateWriter{path: path, now: time.Now}
+	w.jcond = sync.NewCond(&w.jmu)
+	return w
+}
+
+// MaxSize sets the size in bytes the file grows up to before rotated.
+// A non-positive n disables rotation by size.
+func (w *RotateWriter) MaxSize(n int64) *RotateWriter {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.size = n
+	return w
+}
+
+// Every sets the duration a file is written before rotated. A
+// non-positive d disables rotation by time.
+func (w *RotateWriter) Every(d time.Duration) *RotateWriter {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.every = d
+	return w
+}
+
+// Keep sets the number of rotated files to keep; the older ones are
+// removed after rotation. A non-positive n keeps all files.
+func (w *RotateWriter) Keep(n int) *RotateWriter {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.keep = n
+	return w
+}
+
+// Compress sets whether rotated files are compressed by gzip, which are
+// named with extension ".gz" appended.
+func (w *RotateWriter) Compress(gz bool) *RotateWriter {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.gz = gz
+	return w
+}
+
+// Write implements the io.Writer interface. The file is rotated before
+// writing p if the size of the file would exceed the limit, or the time
+// has come. The p is never split into two files.
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.n > 0 && (w.size > 0 && w.n+int64(len(p)) > w.size ||
+		w.every > 0 && w.now().Sub(w.opened) >= w.every) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.f.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Rotate closes the file, renames it with timestamp and opens a new one.
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	return w.rotate()
+}
+
+// Close closes the file, and waits until the compression and removal
+// of rotated files are done. The file is reopened on next Write.
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.f != nil {
+		err = w.f.Close()
+		w.f = nil
+	}
+	w.mu.Unlock()
+
+	w.jmu.Lock()
+	for w.working {
+		w.jcond.Wait()
+	}
+	w.jmu.Unlock()
+	return err
+}
+
+func (w *RotateWriter) open() error {
+	f, err := openAppend(w.path)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.f, w.n, w.opened = f, info.Size(), w.now()
+	return nil
+}
+
+// rotate renames the file and opens a new one. The caller must hold w.mu
+// and w.f must not be nil.
+func (w *RotateWriter) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	w.f = nil
+
+	name := w.backupName(w.now().UTC())
+	if err := os.Rename(w.path, name); err != nil {
+		return err
+	}
+
+	if w.gz || w.keep > 0 {
+		w.schedule(rotateJob{name, w.gz, w.keep})
+	}
+	return w.open()
+}
+
+// backupName returns the name of file rotated at t, which is not used by
+// other rotated files, either compressed or not. The caller must hold
+// w.mu.
+func (w *RotateWriter) backupName(t time.Time) string {
+	ext := filepath.Ext(w.path)
+	base := strings.TrimSuffix(w.path, ext) + "-" + t.Format(backupTime)
+
+	name := base + ext
+	for seq := 1; exists(name) || exists(name+".gz"); seq++ {
+		name = base + "-" + strconv.Itoa(seq) + ext
+	}
+	return name
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// schedule adds j to the pending jobs, and starts the background
+// goroutine if it's not running.
+func (w *RotateWriter) schedule(j rotateJob) {
+	w.jmu.Lock()
+	defer w.jmu.Unlock()
+
+	w.jobs = append(w.jobs, j)
+	if !w.working {
+		w.working = true
+		go w.work()
+	}
+}
+
+// work runs the pending jobs in order until there's none.
+func (w *RotateWriter) work() {
+	for {
+		w.jmu.Lock()
+		if len(w.jobs) == 0 {
+			w.working = false
+			w.jcond.Broadcast()
+			w.jmu.Unlock()
+			return
+		}
+		j := w.jobs[0]
+		w.jobs = w.jobs[1:]
+		w.jmu.Unlock()
+
+		if j.gz {
+			compress(j.name)
+		}
+		if j.keep > 0 {
+			w.cleanup(j.keep)
+		}
+	}
+}
+
+// compress gzips the named file to name.gz and removes the original.
+func compress(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+
+	src.Close()
+	return os.Remove(name)
+}
+
+// cleanup removes the oldest rotated files, keeping the latest n ones.
+func (w *RotateWriter) cleanup(n int) error {
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > n {
+		name := strings.TrimSuffix(backups[0], ".gz")
+		os.Remove(name)
+		os.Remove(name + ".gz")
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// backups returns the paths of rotated files, sorted from the oldest. If
+// a file is found both compressed and not, e.g. in the middle of
+// compression, only the one not compressed is returned.
+func (w *RotateWriter) backups() ([]string, error) {
+	dir, ext := filepath.Dir(w.path), filepath.Ext(w.path)
+	base := strings.TrimSuffix(filepath.Base(w.path), ext) + "-"
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	names, err := d.Readdirnames(-1)
+	d.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	type backup struct {
+		name string
+		t    time.Time
+		seq  int
+	}
+
+	found := make(map[string]bool, len(names))
+	for _, name := range names {
+		found[name] = true
+	}
+
+	var bs []backup
+	for _, name := range names {
+		s := strings.TrimSuffix(name, ".gz")
+		if s != name && found[s] || !strings.HasPrefix(s, base) || !strings.HasSuffix(s, ext) {
+			continue
+		}
+
+		// the timestamp is optionally followed by "-seq"
+		ts, seq := strings.TrimSuffix(s[len(base):], ext), 0
+		if len(ts) > len(backupTime) {
+			if ts[len(backupTime)] != '-' {
+				continue
+			}
+			if seq, err = strconv.Atoi(ts[len(backupTime)+1:]); err != nil || seq <= 0 {
+				continue
+			}
+			ts = ts[:len(backupTime)]
+		}
+
+		if t, err := time.Parse(backupTime, ts); err == nil {
+			bs = append(bs, backup{filepath.Join(dir, name), t, seq})
+		}
+	}
+
+	sort.Slice(bs, func(i, j int) bool {
+		if bs[i].t.Equal(bs[j].t) {
+			return bs[i].seq < bs[j].seq
+		}
+		return bs[i].t.Before(bs[j].t)
+	})
+
+	r := make([]string, len(bs))
+	for i, b := range bs {
+		r[i] = b.name
+	}
+	return r, nil
+}
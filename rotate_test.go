@@ -0,0 +1,241 @@
+package beaver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a function reporting the time which advances
+// one second on every call.
+func fakeClock() func() time.Time {
+	t := time.Date(2018, 2, 6, 0, 31, 28, 0, time.UTC)
+	return func() time.Time {
+		t = t.Add(time.Second)
+		return t
+	}
+}
+
+func TestRotateWriterSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beaver")
+	if err != nil {
+		t.Fatal("ioutil.TempDir exits with error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	w := NewRotateWriter(path).MaxSize(10).Keep(2)
+	w.now = fakeClock()
+
+	for _, s := range []string{"12345", "67890", "abc", "defghijk", "lmn"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal("RotateWriter.Write failed:", err)
+		}
+	}
+	w.Close()
+
+	// "1234567890" | "abc" | "defghijk" | "lmn"; the oldest is removed
+	b, _ := ioutil.ReadFile(path)
+	if string(b) != "lmn" {
+		t.Errorf("RotateWriter failed: current file not match. Got: %s", b)
+	}
+
+	backups, _ := w.backups()
+	if len(backups) != 2 {
+		t.Fatalf("RotateWriter failed: %d rotated files kept, want 2: %v", len(backups), backups)
+	}
+
+	for i, want := range []string{"abc", "defghijk"} {
+		b, _ = ioutil.ReadFile(backups[i])
+		if string(b) != want {
+			t.Errorf("RotateWriter failed: rotated file %s not match. Got: %s, Want: %s", backups[i], b, want)
+		}
+		if !strings.HasPrefix(filepath.Base(backups[i]), "app-2018-02-06T00-31-") || filepath.Ext(backups[i]) != ".log" {
+			t.Errorf("RotateWriter failed: invalid name of rotated file %s", backups[i])
+		}
+	}
+}
+
+func TestRotateWriterTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beaver")
+	if err != nil {
+		t.Fatal("ioutil.TempDir exits with error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	w := NewRotateWriter(path).Every(time.Second).Compress(true)
+	w.now = fakeClock()
+
+	// each call of now advances 1 second; the file opened at 1s is
+	// rotated at 2s, which is also the timestamp of the rotated file
+	w.Write([]byte("first"))
+	w.Write([]byte("second"))
+	w.Close()
+
+	b, _ := ioutil.ReadFile(path)
+	if string(b) != "second" {
+		t.Errorf("RotateWriter failed: current file not match. Got: %s", b)
+	}
+
+	backups, _ := w.backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".log.gz") {
+		t.Fatalf("RotateWriter failed: rotated file not compressed: %v", backups)
+	}
+
+	f, _ := os.Open(backups[0])
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal("gzip.NewReader exits with error:", err)
+	}
+	if b, _ = ioutil.ReadAll(r); string(b) != "first" {
+		t.Errorf("RotateWriter failed: compressed file not match. Got: %s", b)
+	}
+}
+
+func TestRotateWriterClockBack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beaver")
+	if err != nil {
+		t.Fatal("ioutil.TempDir exits with error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// daylight saving time ends between the rotations, so the local
+	// time of the latter is earlier
+	times := []time.Time{
+		time.Date(2018, 10, 28, 2, 30, 0, 0, time.FixedZone("CEST", 2*3600)),
+		time.Date(2018, 10, 28, 2, 10, 0, 0, time.FixedZone("CET", 3600)),
+	}
+	path := filepath.Join(dir, "app.log")
+	w := NewRotateWriter(path).Keep(1)
+	for _, now := range times {
+		w.now = func() time.Time { return now }
+		w.Write([]byte(now.String()))
+		w.Rotate()
+	}
+	w.Close()
+
+	backups, _ := w.backups()
+	if len(backups) != 1 {
+		t.Fatalf("RotateWriter failed: %d rotated files kept, want 1", len(backups))
+	}
+	if b, _ := ioutil.ReadFile(backups[0]); string(b) != times[1].String() {
+		t.Errorf("RotateWriter failed: newer file removed. Got: %s, Want: %s", b, times[1])
+	}
+}
+
+func TestRotateWriterSameTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beaver")
+	if err != nil {
+		t.Fatal("ioutil.TempDir exits with error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// all rotations happen in the same millisecond
+	now := time.Date(2018, 2, 6, 0, 31, 28, 0, time.UTC)
+	for _, gz := range []bool{false, true} {
+		path := filepath.Join(dir, "app.log")
+		w := NewRotateWriter(path).MaxSize(20).Compress(gz)
+		w.now = func() time.Time { return now }
+
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(w, "line %03d\n", i)
+		}
+		w.Close()
+
+		backups, _ := w.backups()
+		if len(backups) != 49 {
+			t.Fatalf("RotateWriter failed: %d rotated files, want 49", len(backups))
+		}
+
+		// lines are kept in order
+		var all []byte
+		for _, name := range append(backups, path) {
+			b, _ := ioutil.ReadFile(name)
+			if gz && name != path {
+				r, err := gzip.NewReader(bytes.NewReader(b))
+				if err != nil {
+					t.Fatalf("gzip.NewReader of %s exits with error: %v", name, err)
+				}
+				b, _ = ioutil.ReadAll(r)
+			}
+			all = append(all, b...)
+		}
+		for i, line := range strings.Split(strings.TrimSuffix(string(all), "\n"), "\n") {
+			if want := fmt.Sprintf("line %03d", i); line != want {
+				t.Fatalf("RotateWriter failed: line %d not match. Got: %s, Want: %s", i, line, want)
+			}
+		}
+
+		// the older ones are removed, whether compressed or not
+		w.Keep(3).Rotate()
+		w.Close()
+		if backups, _ = w.backups(); len(backups) != 3 {
+			t.Errorf("RotateWriter failed: %d rotated files kept, want 3: %v", len(backups), backups)
+		}
+		os.Remove(path)
+		for _, name := range backups {
+			os.Remove(name)
+		}
+	}
+}
+
+func TestRotateWriterCompressKeep(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beaver")
+	if err != nil {
+		t.Fatal("ioutil.TempDir exits with error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	w := NewRotateWriter(path).Compress(true).Keep(2)
+	for i := 0; i < 20; i++ {
+		w.Write([]byte("a line of log\n"))
+		w.Rotate()
+	}
+	w.Close()
+
+	names, _ := filepath.Glob(filepath.Join(dir, "app-*"))
+	if len(names) != 2 {
+		t.Errorf("RotateWriter failed: %d rotated files kept, want 2: %v", len(names), names)
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".log.gz") {
+			t.Errorf("RotateWriter failed: rotated file %s not compressed", name)
+		}
+	}
+}
+
+func TestRotateWriterLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beaver")
+	if err != nil {
+		t.Fatal("ioutil.TempDir exits with error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	w := NewRotateWriter(path)
+	l := NewLogger().Output(w)
+
+	l.Info(message)
+	if err := w.Rotate(); err != nil {
+		t.Fatal("RotateWriter.Rotate failed:", err)
+	}
+	l.Info(message)
+	w.Close()
+
+	backups, _ := w.backups()
+	for _, name := range append(backups, path) {
+		b, _ := ioutil.ReadFile(name)
+		if !reg.Match(b) {
+			t.Errorf("RotateWriter failed: file %s not match. Got: %s", name, b)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package beaver
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A sampler counts the logs by level, name and message in intervals.
+type sampler struct {
+	first, every int
+	tick         time.Duration
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+// allow reports whether the log identified by key is written. The
+// counts are reset at the beginning of each interval.
+func (s *sampler) allow(key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.start) >= s.tick {
+		s.start, s.counts = now, make(map[string]int)
+	}
+
+	s.counts[key]++
+	n := s.counts[key]
+	return n <= s.first || s.every > 0 && (n-s.first)%s.every == 0
+}
+
+// A dedup tracks the last log written, and the number of its repeats
+// suppressed.
+type dedup struct {
+	d time.Duration
+
+	mu    sync.Mutex
+	key   string
+	last  Entry     // level, name and message of the last log
+	t     time.Time // when the last log was written
+	n     int
+	out   *output     // where the repeats are reported when the window expires
+	timer *time.Timer // fires when the window expires, if n > 0
+}
+
+// check reports whether the log e identified by key repeats the last one
+// within the duration, which is then suppressed. Otherwise, e becomes the
+// last one, and an Entry reporting the suppressed repeats of the previous
+// one, if any, is returned. If no other log follows the repeats, they are
+// reported to o when the duration expires.
+func (d *dedup) check(key string, e *Entry, o *output) (skip bool, rep *Entry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if key == d.key && e.Time.Sub(d.t) < d.d {
+		if d.n++; d.n == 1 {
+			d.out = o
+			d.timer = time.AfterFunc(time.Until(d.t.Add(d.d)), d.flush)
+		}
+		return true, nil
+	}
+
+	rep = d.report(e.Time)
+	d.key, d.t = key, e.Time
+	d.last = Entry{Level: e.Level, Tag: e.Tag, Name: e.Name, Message: e.Message}
+	return false, rep
+}
+
+// report returns an Entry at time t reporting the suppressed repeats of
+// the last log, if any, and resets the number of them. The caller must
+// hold d.mu.
+func (d *dedup) report(t time.Time) *Entry {
+	if d.n == 0 {
+		return nil
+	}
+
+	rep := &Entry{
+		Time:    t,
+		Level:   d.last.Level,
+		Tag:     d.last.Tag,
+		Name:    d.last.Name,
+		Message: "message repeated " + strconv.Itoa(d.n) + " times: " + d.last.Message,
+	}
+	d.n = 0
+	d.timer.Stop()
+	return rep
+}
+
+// flush writes the suppressed repeats of the last log, if any.
+func (d *dedup) flush() {
+	d.mu.Lock()
+	rep, o := d.report(time.Now()), d.out
+	d.mu.Unlock()
+
+	if rep != nil {
+		rep.File = "???"
+		o.write(rep, 0)
+	}
+}
+
+// filter reports whether the log e is written after sampling and
+// deduplication of c. If so, an Entry reporting the suppressed repeats of
+// the previous log, if any, is also returned, which is written to o
+// before e.
+func (c *logConfig) filter(e *Entry, o *output) (ok bool, rep *Entry) {
+	if c.sp == nil && c.dd == nil {
+		return true, nil
+	}
+
+	key := sampleKey(e.Level, e.Name, e.Message)
+	if s := c.sp[e.Level]; s != nil && !s.allow(key, e.Time) {
+		return false, nil
+	}
+
+	if c.dd != nil {
+		skip, rep := c.dd.check(key, e, o)
+		return !skip, rep
+	}
+	return true, nil
+}
+
+// sampleKey returns the key which identifies a log in sampling and
+// deduplication.
+func sampleKey(lv int, name, msg string) string {
+	return strconv.Itoa(lv) + "\x00" + name + "\x00" + msg
+}
+
+// Sample samples the logs of levels lv: in each interval tick, the first
+// n logs with the same level, name and message are written, and then
+// every mth one; if m is not positive, the rest are dropped. A
+// non-positive tick disables sampling of the levels. The counts are
+// shared with the children of l created after.
+func (l *Logger) Sample(lv, n, m int, tick time.Duration) *Logger {
+	var s *sampler
+	if tick > 0 {
+		s = &sampler{first: n, every: m, tick: tick}
+	}
+
+	return l.update(func(c *logConfig) {
+		sp := make(map[int]*sampler, len(c.sp))
+		for k, v := range c.sp {
+			sp[k] = v
+		}
+
+		for b := 1; b <= lcustomMax; b <<= 1 {
+			if lv&b == 0 {
+				continue
+			}
+			if s != nil {
+				sp[b] = s
+			} else {
+				delete(sp, b)
+			}
+		}
+		c.sp = sp
+	})
+}
+
+// Flush writes the repeats of the last log suppressed by Dedup, if any.
+// It's called by Exit.
+func (l *Logger) Flush() {
+	if dd := l.config().dd; dd != nil {
+		dd.flush()
+	}
+}
+
+// Dedup collapses the consecutive logs with the same level, name and
+// message written within duration d since the first of them. The
+// repeats are suppressed, and reported as "message repeated X times"
+// before the next log which is written, or when d expires, or by Flush,
+// whichever comes first. A non-positive d disables deduplication. The
+// state is shared with the children of l created after.
+func (l *Logger) Dedup(d time.Duration) *Logger {
+	return l.update(func(c *logConfig) {
+		c.dd = nil
+		if d > 0 {
+			c.dd = &dedup{d: d}
+		}
+	})
+}
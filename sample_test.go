@@ -0,0 +1,109 @@
+package beaver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSample(t *testing.T) {
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w).Flags(0).Sample(Lerror|Lwarn, 2, 3, time.Hour)
+
+	// the 1st, 2nd, 5th and 8th are written
+	for i := 0; i < 10; i++ {
+		l.Error("noisy")
+		l.Info("quiet")
+	}
+	l.Error("another")
+
+	if n := strings.Count(w.String(), "ERROR: noisy\n"); n != 4 {
+		t.Errorf("Logger.Sample failed: %d logs written, want 4", n)
+	}
+	if n := strings.Count(w.String(), "INFO : quiet\n"); n != 10 {
+		t.Errorf("Logger.Sample failed: %d logs of level not sampled written, want 10", n)
+	}
+	if !strings.HasSuffix(w.String(), "ERROR: another\n") {
+		t.Errorf("Logger.Sample failed: different message should be counted separately. Got: %s", w)
+	}
+
+	// disable sampling
+	w.Reset()
+	l.Sample(Lerror, 0, 0, 0)
+	for i := 0; i < 10; i++ {
+		l.Error("noisy")
+	}
+	if n := strings.Count(w.String(), "ERROR: noisy\n"); n != 10 {
+		t.Errorf("Logger.Sample failed: %d logs written after disabled, want 10", n)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w).Flags(0).Dedup(time.Hour)
+
+	for i := 0; i < 5; i++ {
+		l.Error("failed")
+	}
+	l.Named("db").Info("done")
+	l.Info("done")
+
+	want := "ERROR: failed\n" +
+		"ERROR: message repeated 4 times: failed\n" +
+		"INFO : db: done\n" +
+		"INFO : done\n"
+	if w.String() != want {
+		t.Errorf("Logger.Dedup failed.\nGot:\n%s\nWant:\n%s", w, want)
+	}
+
+	// repeats after the duration are written
+	w.Reset()
+	l.Dedup(time.Nanosecond)
+	l.Warn("again")
+	time.Sleep(time.Millisecond)
+	l.Warn("again")
+	if n := strings.Count(w.String(), "WARN : again\n"); n != 2 {
+		t.Errorf("Logger.Dedup failed: %d logs written, want 2", n)
+	}
+
+	// trailing repeats are written by Flush
+	w.Reset()
+	l.Dedup(time.Hour)
+	for i := 0; i < 3; i++ {
+		l.Error("last")
+	}
+	l.Flush()
+	l.Flush()
+	if want = "ERROR: last\nERROR: message repeated 2 times: last\n"; w.String() != want {
+		t.Errorf("Logger.Flush failed.\nGot:\n%s\nWant:\n%s", w, want)
+	}
+}
+
+// A chanWriter sends each write to the channel.
+type chanWriter chan string
+
+func (c chanWriter) Write(p []byte) (int, error) {
+	c <- string(p)
+	return len(p), nil
+}
+
+func TestDedupExpire(t *testing.T) {
+	w := make(chanWriter, 10)
+	l := NewLogger().Output(w).Flags(0).Dedup(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		l.Error("failed")
+	}
+
+	// trailing repeats are written when the duration expires
+	for _, want := range []string{"ERROR: failed\n", "ERROR: message repeated 2 times: failed\n"} {
+		select {
+		case got := <-w:
+			if got != want {
+				t.Errorf("Logger.Dedup failed. Got: %q, Want: %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Logger.Dedup failed: %q not written", want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package beaver
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the HTTP header which carries the signature of a
+// JSON-encoded body. Its value is a JWS in compact serialization with
+// detached payload (RFC 7515, Appendix F), in the form "header..signature".
+const SignatureHeader = "X-Jws-Signature"
+
+// The errors returned by Verifier.
+var (
+	ErrNoSignature  = errors.New("beaver: signature not found")
+	ErrBadSignature = errors.New("beaver: invalid signature")
+	ErrSignatureAge = errors.New("beaver: signature timestamp out of tolerance")
+)
+
+// ErrBodyTooLarge is returned by BindSigned if the body exceeds the limit
+// of Verifier.
+var ErrBodyTooLarge = errors.New("beaver: body too large")
+
+var b64 = base64.RawURLEncoding
+
+// jwsHeader is the protected header of a signature. The Iat is the Unix
+// time when the signature was issued.
+type jwsHeader struct {
+	Alg string `json:"alg"`
+	Iat int64  `json:"iat"`
+}
+
+// A Signer signs JSON-encoded bodies with HMAC-SHA256 or Ed25519.
+type Signer struct {
+	alg  string
+	key  []byte
+	priv ed25519.PrivateKey
+}
+
+// HMACSigner returns a Signer using HMAC-SHA256 with the secret key.
+func HMACSigner(key []byte) *Signer {
+	return &Signer{alg: "HS256", key: key}
+}
+
+// Ed25519Signer returns a Signer using Ed25519 with the private key. It
+// panics if the length of priv is not ed25519.PrivateKeySize.
+func Ed25519Signer(priv ed25519.PrivateKey) *Signer {
+	if len(priv) != ed25519.PrivateKeySize {
+		panic("beaver: invalid Ed25519 private key size")
+	}
+	return &Signer{alg: "EdDSA", priv: priv}
+}
+
+// Sign returns the signature of body, issued at current time, which can
+// be used as value of SignatureHeader.
+func (s *Signer) Sign(body []byte) string {
+	return s.sign(body, time.Now())
+}
+
+func (s *Signer) sign(body []byte, t time.Time) string {
+	hdr, _ := json.Marshal(jwsHeader{s.alg, t.Unix()})
+	in := b64.EncodeToString(hdr) + "." + b64.EncodeToString(body)
+
+	var sig []byte
+	if s.alg == "HS256" {
+		m := hmac.New(sha256.New, s.key)
+		m.Write([]byte(in))
+		sig = m.Sum(nil)
+	} else {
+		sig = ed25519.Sign(s.priv, []byte(in))
+	}
+
+	return in[:strings.IndexByte(in, '.')] + ".." + b64.EncodeToString(sig)
+}
+
+// A Verifier verifies signatures produced by a Signer. Signatures issued
+// too long ago, or too far in the future, are rejected to prevent replay
+// attacks. The default tolerance is 5 minutes, and the bodies read by
+// BindSigned are limited to 10 MiB.
+type Verifier struct {
+	alg string
+	key []byte
+	pub ed25519.PublicKey
+	tol time.Duration
+	max int64
+}
+
+// the default limit of bodies read by BindSigned
+const maxSignedBody = 10 << 20
+
+// HMACVerifier returns a Verifier of HMAC-SHA256 signatures with the
+// secret key.
+func HMACVerifier(key []byte) *Verifier {
+	return &Verifier{alg: "HS256", key: key, tol: 5 * time.Minute, max: maxSignedBody}
+}
+
+// Ed25519Verifier returns a Verifier of Ed25519 signatures with the
+// public key. It panics if the length of pub is not
+// ed25519.PublicKeySize.
+func Ed25519Verifier(pub ed25519.PublicKey) *Verifier {
+	if len(pub) != ed25519.PublicKeySize {
+		panic("beaver: invalid Ed25519 public key size")
+	}
+	return &Verifier{alg: "EdDSA", pub: pub, tol: 5 * time.Minute, max: maxSignedBody}
+}
+
+// Tolerance sets the maximum difference between the time a signature
+// was issued and current time. A non-positive d disables the check.
+func (v *Verifier) Tolerance(d time.Duration) *Verifier {
+	v.tol = d
+	return v
+}
+
+// MaxBody sets the maximum size in bytes of bodies read by BindSigned.
+// A non-positive n removes the limit.
+func (v *Verifier) MaxBody(n int64) *Verifier {
+	v.max = n
+	return v
+}
+
+// Verify checks if sig is a valid signature of body.
+func (v *Verifier) Verify(sig string, body []byte) error {
+	return v.verify(sig, body, time.Now())
+}
+
+func (v *Verifier) verify(sig string, body []byte, now time.Time) error {
+	if sig == "" {
+		return ErrNoSignature
+	}
+
+	parts := strings.Split(sig, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return ErrBadSignature
+	}
+
+	b, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return ErrBadSignature
+	}
+	var hdr jwsHeader
+	if err = json.Unmarshal(b, &hdr); err != nil || hdr.Alg != v.alg {
+		return ErrBadSignature
+	}
+
+	mac, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return ErrBadSignature
+	}
+
+	in := []byte(parts[0] + "." + b64.EncodeToString(body))
+	if v.alg == "HS256" {
+		m := hmac.New(sha256.New, v.key)
+		m.Write(in)
+		if !hmac.Equal(mac, m.Sum(nil)) {
+			return ErrBadSignature
+		}
+	} else if !ed25519.Verify(v.pub, in, mac) {
+		return ErrBadSignature
+	}
+
+	if v.tol > 0 {
+		d := now.Sub(time.Unix(hdr.Iat, 0))
+		if d > v.tol || d < -v.tol {
+			return ErrSignatureAge
+		}
+	}
+	return nil
+}
+
+// SendSigned is equivalent to j.Send() which the request is signed by s.
+// The signature is set in SignatureHeader.
+func (j *JSONPod) SendSigned(method, url string, h http.Header, s *Signer) (*http.Response, error) {
+	var buf bytes.Buffer
+	if err := j.Write(&buf); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+
+	if h != nil {
+		req.Header = h
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(SignatureHeader, s.Sign(buf.Bytes()))
+
+	return (&http.Client{}).Do(req)
+}
+
+// ServeSigned is equivalent to j.Serve() which the response is signed
+// by s. The signature is set in SignatureHeader.
+func (j *JSONPod) ServeSigned(w http.ResponseWriter, code int, s *Signer) error {
+	var buf bytes.Buffer
+	if err := j.Write(&buf); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set(SignatureHeader, s.Sign(buf.Bytes()))
+	w.WriteHeader(code)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// BindSigned reads the body of r, verifies its signature in
+// SignatureHeader with v and stores the JSON-encoded data in j.
+// Nothing is stored if the verification fails. ErrBodyTooLarge is
+// returned if the body exceeds the limit of v, without reading the rest.
+func (j *JSONPod) BindSigned(r *http.Request, v *Verifier) error {
+	var body io.Reader = r.Body
+	if v.max > 0 {
+		body = io.LimitReader(r.Body, v.max+1)
+	}
+
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if v.max > 0 && int64(len(b)) > v.max {
+		return ErrBodyTooLarge
+	}
+
+	if err = v.Verify(r.Header.Get(SignatureHeader), b); err != nil {
+		return err
+	}
+
+	return j.Parse(b)
+}
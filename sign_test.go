@@ -0,0 +1,145 @@
+package beaver
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal("ed25519.GenerateKey exits with error:", err)
+	}
+
+	key := []byte("webhook secret")
+	pairs := map[string]struct {
+		s *Signer
+		v *Verifier
+	}{
+		"HMAC":    {HMACSigner(key), HMACVerifier(key)},
+		"Ed25519": {Ed25519Signer(priv), Ed25519Verifier(pub)},
+	}
+
+	body := []byte(`{"name":"Beaver"}`)
+	now := time.Now()
+	for name, p := range pairs {
+		sig := p.s.sign(body, now)
+		if err := p.v.verify(sig, body, now); err != nil {
+			t.Errorf("%s Verify failed: %v", name, err)
+		}
+
+		if err := p.v.verify(sig, []byte(`{"name":"Otter"}`), now); err != ErrBadSignature {
+			t.Errorf("%s Verify failed: modified body not detected. Got: %v", name, err)
+		}
+
+		if err := p.v.verify(sig, body, now.Add(10*time.Minute)); err != ErrSignatureAge {
+			t.Errorf("%s Verify failed: expired signature not detected. Got: %v", name, err)
+		}
+
+		if err := p.v.verify("", body, now); err != ErrNoSignature {
+			t.Errorf("%s Verify failed: missing signature not detected. Got: %v", name, err)
+		}
+	}
+
+	// signature of an algorithm must not be accepted by another
+	sig := HMACSigner(key).Sign(body)
+	if err := Ed25519Verifier(pub).Verify(sig, body); err != ErrBadSignature {
+		t.Errorf("Verify failed: algorithm mismatch not detected. Got: %v", err)
+	}
+}
+
+func TestSendSignedBindSigned(t *testing.T) {
+	s := sample{
+		Name: "Beaver",
+		Year: 2017,
+		Fast: true,
+	}
+	key := []byte("webhook secret")
+
+	var out sample
+	var berr error
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		berr = JSON(&out).BindSigned(r, HMACVerifier(key))
+	}))
+	defer ts.Close()
+
+	res, err := JSON(&s).SendSigned("POST", ts.URL, nil, HMACSigner(key))
+	if err != nil {
+		t.Fatal("JSONPod.SendSigned failed:", err)
+	}
+	res.Body.Close()
+
+	if berr != nil {
+		t.Fatal("JSONPod.BindSigned failed:", berr)
+	}
+	if out != s {
+		t.Errorf("JSONPod.BindSigned failed\nGot:  %v\nWant: %v", out, s)
+	}
+
+	out = sample{}
+	res, err = JSON(&s).SendSigned("POST", ts.URL, nil, HMACSigner([]byte("wrong")))
+	if err != nil {
+		t.Fatal("JSONPod.SendSigned failed:", err)
+	}
+	res.Body.Close()
+
+	if berr != ErrBadSignature || out != (sample{}) {
+		t.Errorf("JSONPod.BindSigned failed: wrong key not detected. Got: %v, %v", berr, out)
+	}
+}
+
+func TestServeSigned(t *testing.T) {
+	s := sample{Name: "Beaver"}
+	key := []byte("webhook secret")
+
+	w := httptest.NewRecorder()
+	if err := JSON(&s).ServeSigned(w, http.StatusTeapot, HMACSigner(key)); err != nil {
+		t.Fatal("JSONPod.ServeSigned failed:", err)
+	}
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusTeapot {
+		t.Error("JSONPod.ServeSigned doesn't set status code")
+	}
+
+	b, _ := ioutil.ReadAll(res.Body)
+	if err := HMACVerifier(key).Verify(res.Header.Get(SignatureHeader), b); err != nil {
+		t.Error("JSONPod.ServeSigned failed:", err)
+	}
+}
+
+func TestBindSignedTooLarge(t *testing.T) {
+	key := []byte("webhook secret")
+	body := []byte(`{"Name":"Beaver"}`)
+
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	r.Header.Set(SignatureHeader, HMACSigner(key).Sign(body))
+
+	var out sample
+	if err := JSON(&out).BindSigned(r, HMACVerifier(key).MaxBody(10)); err != ErrBodyTooLarge {
+		t.Errorf("JSONPod.BindSigned failed: body over the limit not rejected. Got: %v", err)
+	}
+}
+
+func TestEd25519KeySize(t *testing.T) {
+	for name, fn := range map[string]func(){
+		"Ed25519Signer":   func() { Ed25519Signer(make([]byte, 10)) },
+		"Ed25519Verifier": func() { Ed25519Verifier(make([]byte, 10)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s failed: invalid key size should panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
@@ -0,0 +1,105 @@
+package beaver
+
+import (
+	"io"
+	"sync"
+)
+
+// A Sink is a destination of logs which receives entries instead of
+// encoded bytes. A Logger with sinks writes each log to every Sink
+// enabled at its level, rather than to the Writer set by Output. The
+// level of the Logger is checked before the sinks. The caller of each
+// entry is always reported.
+type Sink interface {
+	// Enabled reports whether the Sink accepts logs of level lv.
+	Enabled(lv int) bool
+
+	// Log writes e to the Sink. It must not retain or modify e.
+	Log(e *Entry) error
+}
+
+// A WriterSink is a Sink which encodes entries by its Formatter and
+// writes them to an io.Writer. It is safe for concurrent use, and can be
+// reconfigured while logging.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+	f  Formatter
+	lv int
+	t  *LTag // nil to keep the tag of Logger
+
+	color bool // whether w is a terminal which colors are written to
+}
+
+// NewWriterSink returns a WriterSink which writes to w. By default, it
+// accepts all levels, uses TextFormatter and keeps the tags of Logger.
+// The colors of tags are removed unless w is a terminal and the
+// environment variable NO_COLOR is empty. The w must not be nil.
+func NewWriterSink(w io.Writer) *WriterSink {
+	if w == nil {
+		panic("A nil pointer can not be used as output")
+	}
+	return &WriterSink{w: w, f: TextFormatter{}, lv: Lall, color: colorable(w)}
+}
+
+// Level sets the levels of logs accepted by s.
+func (s *WriterSink) Level(lv int) *WriterSink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lv = lv
+	return s
+}
+
+// Formatter sets the Formatter of s. The f must not be nil.
+func (s *WriterSink) Formatter(f Formatter) *WriterSink {
+	if f == nil {
+		panic("A nil Formatter can not be used")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.f = f
+	return s
+}
+
+// Tags sets the tags of logs written to s, which replace the ones of
+// Logger.
+func (s *WriterSink) Tags(t LTag) *WriterSink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t = &t
+	return s
+}
+
+// Enabled implements the Sink interface.
+func (s *WriterSink) Enabled(lv int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lv&lv != 0
+}
+
+// Log implements the Sink interface.
+func (s *WriterSink) Log(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag := e.Tag
+	if s.t != nil {
+		tag = s.t.tag(e.Level)
+	}
+	if !s.color {
+		tag = stripColor(tag)
+	}
+	if tag != e.Tag {
+		c := *e
+		c.Tag = tag
+		e = &c
+	}
+
+	b, err := s.f.Format(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(b)
+	return err
+}
@@ -0,0 +1,37 @@
+package beaver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSinks(t *testing.T) {
+	var errs, debug, out bytes.Buffer
+	l := NewLogger().Output(&out).Flags(0).Sinks(
+		NewWriterSink(&errs).Level(Lfatal|Lpanic|Lerror).Tags(BracketLogTag),
+		NewWriterSink(&debug).Formatter(JSONFormatter{}),
+	)
+
+	l.Error("failed")
+	l.With("user", 42).Info("hello")
+
+	if got, want := errs.String(), "[ERROR] failed\n"; got != want {
+		t.Errorf("Logger.Sinks failed: level or tags of sink not respected. Got: %q, Want: %q", got, want)
+	}
+
+	want := `{"level":"error","msg":"failed"}` + "\n" + `{"level":"info","msg":"hello","user":42}` + "\n"
+	if got := debug.String(); got != want {
+		t.Errorf("Logger.Sinks failed: formatter of sink not respected. Got: %q, Want: %q", got, want)
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("Logger.Sinks failed: logs written to output. Got: %q", out.String())
+	}
+
+	// the output is restored without sinks
+	l.Sinks().Warn("restored")
+	if got := out.String(); !strings.HasSuffix(got, "restored\n") {
+		t.Errorf("Logger.Sinks failed: output not restored. Got: %q", got)
+	}
+}
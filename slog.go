@@ -0,0 +1,188 @@
+//go:build go1.21
+// +build go1.21
+
+package beaver
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// fromSlog maps slog level lv to the level of Logger. Levels from
+// slog.LevelError+4 are mapped to Lfatal, the ones between it and
+// slog.LevelError to Lpanic, and below slog.LevelDebug to Ltrace.
+func fromSlog(lv slog.Level) int {
+	switch {
+	case lv >= slog.LevelError+4:
+		return Lfatal
+	case lv > slog.LevelError:
+		return Lpanic
+	case lv > slog.LevelWarn:
+		return Lerror
+	case lv > slog.LevelInfo:
+		return Lwarn
+	case lv > slog.LevelDebug:
+		return Linfo
+	case lv > slog.LevelDebug-4:
+		return Ldebug
+	}
+	return Ltrace
+}
+
+// toSlog maps level lv of Logger to slog level. Lfatal is mapped to
+// slog.LevelError+4, Lpanic to slog.LevelError+2, Ltrace to
+// slog.LevelDebug-4 and custom levels to slog.LevelInfo.
+func toSlog(lv int) slog.Level {
+	switch lv {
+	case Lfatal:
+		return slog.LevelError + 4
+	case Lpanic:
+		return slog.LevelError + 2
+	case Lerror:
+		return slog.LevelError
+	case Lwarn:
+		return slog.LevelWarn
+	case Ldebug:
+		return slog.LevelDebug
+	case Ltrace:
+		return slog.LevelDebug - 4
+	}
+	return slog.LevelInfo
+}
+
+// A slogHandler is a slog.Handler backed by a Logger.
+type slogHandler struct {
+	l     *Logger
+	group string // prefix of keys, ends with "."
+}
+
+// NewSlogHandler returns a slog.Handler which writes records to l. The
+// slog levels are mapped to the ones of Logger, as well as the tags in
+// LTag of l. Attributes are written as fields of logs, which keys of
+// groups are joined by dots. Records at levels above slog.LevelError
+// are written with the panic or fatal tag, but the handler never panics
+// nor exits.
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// Enabled implements the slog.Handler interface.
+func (h *slogHandler) Enabled(_ context.Context, lv slog.Level) bool {
+	return h.l.level()&fromSlog(lv) != 0
+}
+
+// Handle implements the slog.Handler interface.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	kv := h.l.kv[:len(h.l.kv):len(h.l.kv)]
+	r.Attrs(func(a slog.Attr) bool {
+		kv = appendAttr(kv, h.group, a)
+		return true
+	})
+
+	lv := fromSlog(r.Level)
+	c := h.l.config()
+	e := &Entry{
+		Time:    r.Time,
+		Level:   lv,
+		Tag:     c.t.tag(lv),
+		Name:    h.l.name,
+		Message: r.Message,
+		Fields:  kv,
+		File:    "???",
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		e.File, e.Line, e.pc = f.File, f.Line, r.PC
+	}
+
+	ok, rep := c.filter(e, h.l.out)
+	if !ok {
+		return nil
+	}
+	if rep != nil {
+		rep.File, rep.Line, rep.pc = e.File, e.Line, e.pc
+		h.l.out.write(rep, 0)
+	}
+	h.l.out.write(e, 0)
+	return nil
+}
+
+// WithAttrs implements the slog.Handler interface.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var kv []interface{}
+	for _, a := range attrs {
+		kv = appendAttr(kv, h.group, a)
+	}
+	return &slogHandler{h.l.With(kv...), h.group}
+}
+
+// WithGroup implements the slog.Handler interface.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{h.l, h.group + name + "."}
+}
+
+// appendAttr appends the key-value pairs of a to kv, which keys are
+// prefixed with group. Empty attributes are ignored, and groups are
+// flattened.
+func appendAttr(kv []interface{}, group string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kv
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		return append(kv, group+a.Key, a.Value.Any())
+	}
+
+	if a.Key != "" {
+		group += a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		kv = appendAttr(kv, group, ga)
+	}
+	return kv
+}
+
+// A slogOutput passes the entries of a Logger to a slog.Handler.
+type slogOutput struct {
+	h slog.Handler
+}
+
+func (o slogOutput) handle(e *Entry) {
+	lv := toSlog(e.Level)
+	if !o.h.Enabled(context.Background(), lv) {
+		return
+	}
+
+	r := slog.NewRecord(e.Time, lv, e.Message, e.pc)
+	if e.Name != "" {
+		r.AddAttrs(slog.String("logger", e.Name))
+	}
+	if e.Prefix != "" {
+		r.AddAttrs(slog.String("prefix", e.Prefix))
+	}
+	r.Add(e.Fields...)
+	o.h.Handle(context.Background(), r)
+}
+
+// NewSlogLogger returns a Logger which writes logs to slog.Handler h.
+// The levels are mapped to slog levels, where Lfatal is mapped to
+// slog.LevelError+4, Lpanic to slog.LevelError+2, Ltrace to
+// slog.LevelDebug-4 and custom levels to slog.LevelInfo. The name and
+// prefix of the Logger, if any, are added as attributes "logger" and
+// "prefix", followed by the fields. The tags, flags, Formatter and
+// output of the Logger are ignored, since h is responsible for the
+// encoding.
+func NewSlogLogger(h slog.Handler) *Logger {
+	l := NewLogger()
+	l.out.update(func(c *outConfig) { c.h = slogOutput{h} })
+	return l
+}
@@ -0,0 +1,81 @@
+//go:build go1.21
+// +build go1.21
+
+package beaver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w).Level(Lfatal | Lerror | Lwarn | Linfo).Flags(log.Lshortfile)
+	s := slog.New(NewSlogHandler(l))
+
+	s.With("app", "beaver").WithGroup("req").Info(message, "id", 7, slog.Group("user", "name", "otter"))
+
+	r := regexp.MustCompile("^slog_test.go:[0-9]+: INFO : " + message + " app=beaver req.id=7 req.user.name=otter\n$")
+	if !r.Match(w.Bytes()) {
+		t.Errorf("SlogHandler failed. Got: %v", w)
+	}
+
+	w.Reset()
+	s.Debug(message)
+	if w.Len() != 0 {
+		t.Errorf("SlogHandler failed: level not respected. Got: %v", w)
+	}
+
+	s.Log(context.Background(), slog.LevelError+4, message)
+	if !strings.Contains(w.String(), "FATAL: "+message) {
+		t.Errorf("SlogHandler failed: high level should be mapped to Lfatal. Got: %v", w)
+	}
+}
+
+func TestSlogHandlerDedup(t *testing.T) {
+	w := new(bytes.Buffer)
+	l := NewLogger().Output(w).Flags(0).Dedup(time.Hour)
+	s := slog.New(NewSlogHandler(l))
+
+	for i := 0; i < 3; i++ {
+		s.Error(message)
+	}
+	l.Info("done")
+
+	want := "ERROR: " + message + "\nERROR: message repeated 2 times: " + message + "\nINFO : done\n"
+	if w.String() != want {
+		t.Errorf("SlogHandler failed: logs not deduplicated.\nGot:\n%s\nWant:\n%s", w, want)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	w := new(bytes.Buffer)
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true, Level: slog.LevelInfo})
+	l := NewSlogLogger(h).Prefix("app")
+
+	l.With("user", 42).Warnw(message, "ms", 3)
+
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(w.Bytes(), &out); err != nil {
+		t.Fatalf("SlogLogger failed: invalid JSON %s: %v", w, err)
+	}
+
+	src, _ := out["source"].(map[string]interface{})
+	if out["level"] != "WARN" || out["msg"] != message || out["prefix"] != "app" ||
+		out["user"] != 42.0 || out["ms"] != 3.0 || !strings.HasSuffix(src["file"].(string), "slog_test.go") {
+		t.Errorf("SlogLogger failed. Got: %s", w)
+	}
+
+	w.Reset()
+	l.Debug(message)
+	if w.Len() != 0 {
+		t.Errorf("SlogLogger failed: level of handler not respected. Got: %v", w)
+	}
+}
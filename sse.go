@@ -0,0 +1,228 @@
+package beaver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An Event is a Server-Sent Event which Data is JSON-encoded.
+// The ID and Event fields are omitted if empty.
+type Event struct {
+	ID    string
+	Event string
+	Data  interface{}
+}
+
+// A SSEWriter writes Server-Sent Events to a http.ResponseWriter,
+// flushing each event immediately. It is safe for concurrent use.
+type SSEWriter struct {
+	w  http.ResponseWriter
+	f  http.Flusher
+	mu sync.Mutex
+}
+
+// NewSSEWriter sets the headers of a event stream, writes status OK to
+// w and returns a SSEWriter. An error is returned if w doesn't implement
+// http.Flusher. Additional response headers must be set before calling
+// NewSSEWriter.
+func NewSSEWriter(w http.ResponseWriter) (*SSEWriter, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("beaver: ResponseWriter doesn't support flushing")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+
+	return &SSEWriter{w: w, f: f}, nil
+}
+
+// errEventField is returned by Send if the ID or Event field contains
+// newlines, which would inject fields into the stream.
+var errEventField = errors.New("beaver: event ID and type must not contain CR or LF")
+
+// Send writes e to the stream. It returns an error if the ID or Event
+// field of e contains CR or LF.
+func (s *SSEWriter) Send(e Event) error {
+	if strings.ContainsAny(e.ID, "\r\n") || strings.ContainsAny(e.Event, "\r\n") {
+		return errEventField
+	}
+
+	var buf bytes.Buffer
+	if e.ID != "" {
+		buf.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		buf.WriteString("event: " + e.Event + "\n")
+	}
+
+	// JSON-encoded data never contains newline except the trailing one
+	buf.WriteString("data: ")
+	if err := JSON(e.Data).Write(&buf); err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+
+	return s.write(buf.Bytes())
+}
+
+// Heartbeat writes a comment line to the stream, which keeps the
+// connection alive and is ignored by clients.
+func (s *SSEWriter) Heartbeat() error {
+	return s.write([]byte(":\n\n"))
+}
+
+func (s *SSEWriter) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	s.f.Flush()
+	return nil
+}
+
+// ServeSSE streams the events received from ch to client until ch is
+// closed or the context of r is done. A heartbeat is sent if no event
+// is sent within duration d; no heartbeat is sent if d is not positive.
+// It returns nil if ch is closed, or the context error.
+func ServeSSE(w http.ResponseWriter, r *http.Request, ch <-chan Event, d time.Duration) error {
+	s, err := NewSSEWriter(w)
+	if err != nil {
+		return err
+	}
+
+	var t *time.Timer
+	var tick <-chan time.Time
+	if d > 0 {
+		t = time.NewTimer(d)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return r.Context().Err()
+		case e, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			err = s.Send(e)
+		case <-tick:
+			err = s.Heartbeat()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// restart the wait of heartbeat
+		if t != nil {
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
+				}
+			}
+			t.Reset(d)
+		}
+	}
+}
+
+// An EventStream reads Server-Sent Events from a HTTP server.
+type EventStream struct {
+	body io.ReadCloser
+	r    *bufio.Reader
+	id   string
+}
+
+// Subscribe connects to the event stream at url with given header. If h
+// is nil, a default http.Header is applied. The stream is closed when
+// ctx is done. A non-2xx status code from server causes an error.
+func Subscribe(ctx context.Context, url string, h http.Header) (*EventStream, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	if h != nil {
+		req.Header = h
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, errors.New("beaver: Server response with status " + res.Status)
+	}
+
+	return &EventStream{body: res.Body, r: bufio.NewReader(res.Body)}, nil
+}
+
+// Next reads the next event from the stream and decodes its data into j.
+// The Data field of returned Event is j's value. Comments and events
+// without data are skipped. The ID is inherited from previous events if
+// it is not set, as the specification describes. It returns io.EOF when
+// the stream ends.
+func (s *EventStream) Next(j *JSONPod) (Event, error) {
+	e := Event{}
+	var data []string
+
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line != "" {
+				err = io.ErrUnexpectedEOF
+			}
+			return e, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		// an empty line dispatches the event
+		if line == "" {
+			if data == nil {
+				e.Event = ""
+				continue
+			}
+
+			e.ID = s.id
+			e.Data = j.v
+			return e, j.Parse([]byte(strings.Join(data, "\n")))
+		}
+
+		field, val := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, val = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "id":
+			s.id = val
+		case "event":
+			e.Event = val
+		case "data":
+			data = append(data, val)
+		}
+	}
+}
+
+// Close closes the stream.
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,153 @@
+package beaver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	s, err := NewSSEWriter(w)
+	if err != nil {
+		t.Fatal("NewSSEWriter failed:", err)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("NewSSEWriter failed: Content-Type header not set. Got: %s", ct)
+	}
+
+	s.Send(Event{ID: "1", Event: "update", Data: &sample{Name: "Beaver"}})
+	s.Heartbeat()
+	s.Send(Event{Data: 2017})
+
+	want := "id: 1\nevent: update\ndata: {\"name\":\"Beaver\",\"year\":0,\"fast\":false}\n\n" +
+		":\n\n" +
+		"data: 2017\n\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("SSEWriter failed\nGot:  %q\nWant: %q", got, want)
+	}
+	if !w.Flushed {
+		t.Error("SSEWriter failed: events not flushed")
+	}
+}
+
+func TestSSEWriterInjection(t *testing.T) {
+	w := httptest.NewRecorder()
+	s, err := NewSSEWriter(w)
+	if err != nil {
+		t.Fatal("NewSSEWriter failed:", err)
+	}
+
+	for _, e := range []Event{
+		{ID: "1\ndata: {}", Data: 1},
+		{Event: "update\r", Data: 1},
+	} {
+		if err := s.Send(e); err == nil {
+			t.Errorf("SSEWriter.Send failed: newline in %+v should cause an error", e)
+		}
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("SSEWriter.Send failed: invalid event written. Got: %q", w.Body.String())
+	}
+}
+
+func TestServeSSE(t *testing.T) {
+	ch := make(chan Event)
+	done := make(chan error, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done <- ServeSSE(w, r, ch, 10*time.Millisecond)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	es, err := Subscribe(ctx, ts.URL, nil)
+	if err != nil {
+		t.Fatal("Subscribe failed:", err)
+	}
+	defer es.Close()
+
+	events := []Event{
+		{ID: "1", Event: "create", Data: &sample{Name: "Beaver", Year: 2017}},
+		{Event: "update", Data: &sample{Name: "Beaver", Year: 2018, Fast: true}},
+	}
+	go func() {
+		for _, e := range events {
+			time.Sleep(20 * time.Millisecond) // let heartbeats interleave
+			ch <- e
+		}
+	}()
+
+	for _, want := range events {
+		out := sample{}
+		e, err := es.Next(JSON(&out))
+		if err != nil {
+			t.Fatal("EventStream.Next failed:", err)
+		}
+
+		// id is inherited from previous event
+		if e.ID != "1" || e.Event != want.Event || out != *want.Data.(*sample) || e.Data != &out {
+			t.Errorf("EventStream.Next failed\nGot:  %+v %+v\nWant: %+v %+v", e, out, want, want.Data)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("ServeSSE failed: should return context error. Got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("ServeSSE failed: not stopped after client disconnected")
+	}
+}
+
+func TestServeSSEHeartbeat(t *testing.T) {
+	ch := make(chan Event)
+	go func() {
+		for i := 0; i < 30; i++ {
+			time.Sleep(5 * time.Millisecond)
+			ch <- Event{Data: i}
+		}
+		close(ch)
+	}()
+
+	// no heartbeat while events are sent
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	if err := ServeSSE(w, r, ch, 60*time.Millisecond); err != nil {
+		t.Fatal("ServeSSE failed:", err)
+	}
+	if n := strings.Count(w.Body.String(), ":\n\n"); n != 0 {
+		t.Errorf("ServeSSE failed: %d heartbeats sent between events", n)
+	}
+}
+
+func TestEventStreamEOF(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, strings.NewReader(": comment\n\nevent: empty\n\ndata: [1,\ndata: 2]\n\n"))
+	}))
+	defer ts.Close()
+
+	es, err := Subscribe(context.Background(), ts.URL, nil)
+	if err != nil {
+		t.Fatal("Subscribe failed:", err)
+	}
+	defer es.Close()
+
+	var out []int
+	e, err := es.Next(JSON(&out))
+	if err != nil || e.Event != "" || len(out) != 2 || out[1] != 2 {
+		t.Errorf("EventStream.Next failed: multi-line data should be joined. Got: %+v %v, error: %v", e, out, err)
+	}
+
+	if _, err = es.Next(JSON(&out)); err != io.EOF {
+		t.Errorf("EventStream.Next failed: should return io.EOF. Got: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package beaver
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// the layout of TIMESTAMP in RFC 5424
+const syslogTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// the paths of local syslog sockets, tried in order
+var syslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogSeverity returns the syslog severity of level lv. The fatal and
+// panic levels are critical, trace is debug, and custom levels are notice.
+func syslogSeverity(lv int) int {
+	switch lv {
+	case Lfatal, Lpanic:
+		return 2
+	case Lerror:
+		return 3
+	case Lwarn:
+		return 4
+	case Linfo:
+		return 6
+	case Ldebug, Ltrace:
+		return 7
+	}
+	return 5
+}
+
+// A SyslogSink is a Sink which sends entries to a syslog daemon in the
+// format of RFC 5424. The fields of entries are appended to the message
+// in logfmt style. If a write fails, the connection is redialed once.
+// It is safe for concurrent use.
+type SyslogSink struct {
+	network, addr string
+	tag, host     string
+
+	mu    sync.Mutex
+	conn  net.Conn
+	proto string // the network of conn
+	lv    int
+	fac   int
+}
+
+// DialSyslog connects to the syslog daemon at addr on the named network,
+// which is one of "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix"
+// and "unixgram". If network is empty, the local daemon is connected
+// through a unix socket, which is addr or, if addr is empty, the first
+// one found of "/dev/log", "/var/run/syslog" and "/var/run/log". The tag
+// is the APP-NAME of messages; if it's empty, the base name of the
+// program is applied. By default, the sink accepts all levels with
+// facility user.
+func DialSyslog(network, addr, tag string) (*SyslogSink, error) {
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "-"
+	}
+
+	s := &SyslogSink{network: network, addr: addr, tag: tag, host: host, lv: Lall, fac: 1}
+	if err = s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Level sets the levels of logs accepted by s.
+func (s *SyslogSink) Level(lv int) *SyslogSink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lv = lv
+	return s
+}
+
+// Facility sets the facility code of messages, e.g. 3 for daemon or
+// 16 for local0, as defined in RFC 5424.
+func (s *SyslogSink) Facility(f int) *SyslogSink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fac = f
+	return s
+}
+
+// Enabled implements the Sink interface.
+func (s *SyslogSink) Enabled(lv int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lv&lv != 0
+}
+
+// Log implements the Sink interface.
+func (s *SyslogSink) Log(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := s.format(e)
+	if s.conn != nil {
+		if _, err := s.conn.Write(s.frame(msg)); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	if err := s.connect(); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(s.frame(msg))
+	return err
+}
+
+// Close closes the connection.
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// connect dials the daemon. The caller must hold s.mu unless s is not
+// yet shared.
+func (s *SyslogSink) connect() error {
+	if s.network != "" {
+		c, err := net.Dial(s.network, s.addr)
+		if err != nil {
+			return err
+		}
+		s.conn, s.proto = c, s.network
+		return nil
+	}
+
+	paths := syslogPaths
+	if s.addr != "" {
+		paths = []string{s.addr}
+	}
+	for _, p := range paths {
+		for _, n := range []string{"unixgram", "unix"} {
+			if c, err := net.Dial(n, p); err == nil {
+				s.conn, s.proto = c, n
+				return nil
+			}
+		}
+	}
+	return errors.New("beaver: local syslog daemon not found")
+}
+
+// format returns e in the format of RFC 5424.
+func (s *SyslogSink) format(e *Entry) []byte {
+	var b bytes.Buffer
+	b.WriteString("<" + strconv.Itoa(s.fac*8+syslogSeverity(e.Level)) + ">1 ")
+	b.WriteString(e.Time.Format(syslogTime) + " " + s.host + " " + s.tag + " ")
+	b.WriteString(strconv.Itoa(os.Getpid()) + " - - ")
+
+	if c := e.caller(); c != "" {
+		b.WriteString(c + ": ")
+	}
+	if e.Name != "" {
+		b.WriteString(e.Name + ": ")
+	}
+	b.WriteString(e.Message)
+	appendLogfmt(&b, e.Fields)
+	return b.Bytes()
+}
+
+// frame returns msg framed according to the network of connection:
+// octet-counted over TCP, terminated by newline over a unix stream
+// socket, or as is over datagrams.
+func (s *SyslogSink) frame(msg []byte) []byte {
+	switch s.proto {
+	case "tcp", "tcp4", "tcp6":
+		return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	case "unix":
+		return append(msg[:len(msg):len(msg)], '\n')
+	}
+	return msg
+}
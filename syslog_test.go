@@ -0,0 +1,90 @@
+package beaver
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// regex of a RFC 5424 message sent by TestSyslogSink
+var regSyslog = regexp.MustCompile(`^<(\d+)>1 \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}(Z|[+-]\d{2}:\d{2}) \S+ beaver \d+ - - (.*)$`)
+
+func TestSyslogSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("net.Listen exits with error:", err)
+	}
+	defer ln.Close()
+
+	s, err := DialSyslog("tcp", ln.Addr().String(), "beaver")
+	if err != nil {
+		t.Fatal("DialSyslog exits with error:", err)
+	}
+	defer s.Close()
+
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal("Listener.Accept exits with error:", err)
+	}
+	defer c.Close()
+
+	l := NewLogger().Flags(0).Sinks(s.Level(Lerror | Lwarn).Facility(16))
+	l.Info("ignored")
+	l.Named("db").Errorw("failed", "user", 42)
+	l.Warn(message)
+
+	// messages are octet-counted
+	r := bufio.NewReader(c)
+	for _, want := range []struct {
+		pri int
+		msg string
+	}{
+		{16*8 + 3, "db: failed user=42"},
+		{16*8 + 4, message},
+	} {
+		n, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal("SyslogSink failed: reading length:", err)
+		}
+		size, _ := strconv.Atoi(strings.TrimSuffix(n, " "))
+		b := make([]byte, size)
+		if _, err = r.Read(b); err != nil {
+			t.Fatal("SyslogSink failed: reading message:", err)
+		}
+
+		m := regSyslog.FindSubmatch(b)
+		if m == nil || string(m[1]) != strconv.Itoa(want.pri) || string(m[3]) != want.msg {
+			t.Errorf("SyslogSink failed. Got: %q, Want priority %d and message %q", b, want.pri, want.msg)
+		}
+	}
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("net.ListenPacket exits with error:", err)
+	}
+	defer pc.Close()
+
+	s, err := DialSyslog("udp", pc.LocalAddr().String(), "beaver")
+	if err != nil {
+		t.Fatal("DialSyslog exits with error:", err)
+	}
+	defer s.Close()
+
+	NewLogger().Flags(0).Sinks(s).Debug(message)
+
+	b := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal("SyslogSink failed: reading datagram:", err)
+	}
+
+	m := regSyslog.FindSubmatch(b[:n])
+	if m == nil || string(m[1]) != "15" || string(m[3]) != message {
+		t.Errorf("SyslogSink failed. Got: %q", b[:n])
+	}
+}
@@ -0,0 +1,97 @@
+package beaver
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+)
+
+// The field names of parts in a multipart upload.
+const (
+	UploadMetaField = "metadata"
+	UploadFileField = "file"
+)
+
+// Upload posts the files in given paths to url as multipart/form-data
+// with header h. If meta is not nil, its JSON-encoded data is sent as
+// the first part, named UploadMetaField. Each file is sent in a part
+// named UploadFileField. The files are streamed and never buffered in
+// memory. If h is nil, a default http.Header is applied.
+//
+// Like Download, a non-2xx status code from server causes an error.
+// Otherwise, it is the caller's responsibility to close the response's
+// Body.
+func Upload(h http.Header, url string, meta *JSONPod, paths ...string) (*http.Response, error) {
+	r, w := io.Pipe()
+	mw := multipart.NewWriter(w)
+	go func() {
+		w.CloseWithError(writeParts(mw, meta, paths))
+	}()
+
+	req, err := http.NewRequest("POST", url, r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	if h != nil {
+		req.Header = h
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	res, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, errors.New("beaver: Server response with status " + res.Status)
+	}
+	return res, nil
+}
+
+// writeParts writes meta and the files to mw and closes it.
+func writeParts(mw *multipart.Writer, meta *JSONPod, paths []string) error {
+	if meta != nil {
+		hdr := make(textproto.MIMEHeader)
+		hdr.Set("Content-Disposition", `form-data; name="`+UploadMetaField+`"`)
+		hdr.Set("Content-Type", "application/json; charset=utf-8")
+
+		p, err := mw.CreatePart(hdr)
+		if err != nil {
+			return err
+		}
+		if err = meta.Write(p); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range paths {
+		if err := writeFile(mw, path); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+func writeFile(mw *multipart.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p, err := mw.CreateFormFile(UploadFileField, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(p, f)
+	return err
+}
@@ -0,0 +1,94 @@
+package beaver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestUpload(t *testing.T) {
+	files := map[string]string{
+		"temp1.txt": str,
+		"temp2.txt": "Another file",
+	}
+	for name, s := range files {
+		if err := ioutil.WriteFile(name, []byte(s), 0644); err != nil {
+			t.Fatal("ioutil.WriteFile exits with error:", err)
+		}
+		defer os.Remove(name)
+	}
+
+	s := sample{
+		Name: "Beaver",
+		Year: 2017,
+		Fast: true,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Cache-Control") != "no-cache" {
+			t.Error("Upload failed: header not set")
+		}
+
+		mr, err := r.MultipartReader()
+		if err != nil {
+			t.Error("Request.MultipartReader exits with error:", err)
+			return
+		}
+
+		p, err := mr.NextPart()
+		if err != nil {
+			t.Error("Reader.NextPart exits with error:", err)
+			return
+		}
+		if p.FormName() != UploadMetaField || p.Header.Get("Content-Type") != "application/json; charset=utf-8" {
+			t.Errorf("Upload failed: first part should be metadata. Got: %s %v", p.FormName(), p.Header)
+		}
+
+		out := sample{}
+		b, _ := ioutil.ReadAll(p)
+		if err = JSON(&out).Parse(b); err != nil || out != s {
+			t.Errorf("Upload failed: metadata not match. Got: %s, error: %v", b, err)
+		}
+
+		n := 0
+		for p, err = mr.NextPart(); err == nil; p, err = mr.NextPart() {
+			b, _ = ioutil.ReadAll(p)
+			if p.FormName() != UploadFileField || files[p.FileName()] != string(b) {
+				t.Errorf("Upload failed: file part not match. Got: %s %s: %s", p.FormName(), p.FileName(), b)
+			}
+			n++
+		}
+		if n != len(files) {
+			t.Errorf("Upload failed: %d files received, want %d", n, len(files))
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	h := make(http.Header)
+	h.Set("Cache-Control", "no-cache")
+	res, err := Upload(h, ts.URL, JSON(&s), "temp1.txt", "temp2.txt")
+	if err != nil {
+		t.Fatal("Upload failed:", err)
+	}
+	res.Body.Close()
+}
+
+func TestUploadError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	if _, err := Upload(nil, ts.URL, nil); err == nil {
+		t.Error("Upload failed: non-2xx status code should cause an error")
+	}
+
+	if _, err := Upload(nil, ts.URL, nil, "file_not_exist"); err == nil {
+		t.Error("Upload failed: missing file should cause an error")
+	}
+}